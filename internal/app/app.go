@@ -26,6 +26,7 @@ var (
 			"original code base using the 'l' parameter",
 	)
 	ErrBundleIncomplete = errors.New("bundle contains incomplete catalogs")
+	ErrBundleStale      = errors.New("bundle is out of date, rerun `toki generate`")
 )
 
 var (
@@ -36,12 +37,15 @@ var (
 
 const MainBundleFileGo = "bundle_gen.go"
 
+// InterpreterFileGo is the bundle file providing LoadCatalog (-interpreter).
+const InterpreterFileGo = "interpreter_gen.go"
+
 func Run(
 	osArgs, env []string, stderr, stdout io.Writer, now time.Time,
 ) (result Result, exitCode int) {
 	if len(osArgs) < 2 {
 		return Result{
-			Err: fmt.Errorf("%w, use either of: [generate,lint,webedit]", ErrNoCommand),
+			Err: fmt.Errorf("%w, use either of: [generate,lint,webedit,stats,prune]", ErrNoCommand),
 		}, 2
 	}
 
@@ -57,7 +61,7 @@ func Run(
 			tikICUTranslator: tik.NewICUTranslator(tik.DefaultConfig),
 		}
 		lintOnly := osArgs[1] == "lint"
-		r := g.Run(osArgs, env, lintOnly, stderr, now)
+		r := g.Run(osArgs, env, lintOnly, stderr, stdout, now)
 		switch {
 		case errors.Is(r.Err, ErrInvalidCLIArgs):
 			return r, 2
@@ -80,9 +84,33 @@ func Run(
 			return Result{Err: err}, 1
 		}
 		return Result{}, 0
+	case "stats":
+		s := Stats{
+			hasher:           xxhash.New(),
+			tikParser:        tik.NewParser(tik.DefaultConfig),
+			tikICUTranslator: tik.NewICUTranslator(tik.DefaultConfig),
+		}
+		err := s.Run(osArgs, env, stderr, stdout)
+		switch {
+		case errors.Is(err, ErrInvalidCLIArgs):
+			return Result{Err: err}, 2
+		case err != nil:
+			return Result{Err: err}, 1
+		}
+		return Result{}, 0
+	case "prune":
+		var p Prune
+		err := p.Run(osArgs, stderr, now)
+		switch {
+		case errors.Is(err, ErrInvalidCLIArgs):
+			return Result{Err: err}, 2
+		case err != nil:
+			return Result{Err: err}, 1
+		}
+		return Result{}, 0
 	}
 	return Result{
-		Err: fmt.Errorf("%w %q, use either of: [generate,lint,webedit]",
+		Err: fmt.Errorf("%w %q, use either of: [generate,lint,webedit,stats,prune]",
 			ErrUnknownCommand, osArgs[1]),
 	}, 2
 }
@@ -0,0 +1,20 @@
+package app
+
+import (
+	"slices"
+
+	"github.com/romshark/toki/internal/codeparse"
+)
+
+// findPartialTexts returns the texts of scan found in only some
+// of the scanned build configurations ordered by position.
+func findPartialTexts(scan *codeparse.Scan) []codeparse.Text {
+	var partial []codeparse.Text
+	for text := range scan.Texts.SeqRead() {
+		if len(text.Builds) > 0 && len(text.Builds) < len(scan.Builds) {
+			partial = append(partial, text)
+		}
+	}
+	slices.SortFunc(partial, comparePositions)
+	return partial
+}
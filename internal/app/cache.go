@@ -0,0 +1,53 @@
+package app
+
+import (
+	"bytes"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/romshark/toki/internal/codeparse"
+	"github.com/romshark/toki/internal/log"
+)
+
+// EnvCache is the environment variable to override the scan cache directory.
+// Setting it to "off" disables the scan cache.
+const EnvCache = "TOKICACHE"
+
+// newScanCache returns the scan cache in $TOKICACHE defaulting to $GOCACHE/toki.
+// Returns nil if the cache is disabled or the Go build cache can't be determined.
+func newScanCache(env []string) *codeparse.Cache {
+	dir := lookupEnv(env, EnvCache)
+	switch dir {
+	case "off":
+		return nil
+	case "":
+		goCache := lookupEnv(env, "GOCACHE")
+		if goCache == "" {
+			cmd := exec.Command("go", "env", "GOCACHE")
+			cmd.Env = env
+			out, err := cmd.Output()
+			if err != nil {
+				log.Verbose("scan cache disabled", slog.String("err", err.Error()))
+				return nil
+			}
+			goCache = string(bytes.TrimSpace(out))
+		}
+		if goCache == "" || goCache == "off" {
+			return nil
+		}
+		dir = filepath.Join(goCache, "toki")
+	}
+	return codeparse.NewCache(dir, Version)
+}
+
+// lookupEnv returns the value of the last definition of key in env.
+func lookupEnv(env []string, key string) string {
+	for i := len(env) - 1; i >= 0; i-- {
+		if v, ok := strings.CutPrefix(env[i], key+"="); ok {
+			return v
+		}
+	}
+	return ""
+}
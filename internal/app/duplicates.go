@@ -0,0 +1,161 @@
+package app
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/romshark/toki/internal/codeparse"
+	"github.com/romshark/toki/internal/levenshtein"
+
+	"github.com/romshark/tik/tik-go"
+)
+
+// nearDuplicateRatio is the maximum edit distance between two normalized TIKs
+// relative to the length of the longer one for them to be near-duplicates.
+const nearDuplicateRatio = 0.1
+
+// nearDuplicateMinLen is the minimum length in runes of normalized TIKs
+// to be compared by edit distance. Shorter TIKs, such as "Save" and "Sale",
+// are only near-duplicates if their normalized forms are equal.
+const nearDuplicateMinLen = 10
+
+// NearDuplicates is a group of texts of the same domain that differ only
+// in letter case, whitespace, trailing punctuation, placeholder naming
+// or a small number of edits and are therefore likely to mean the same.
+type NearDuplicates struct {
+	Keep  codeparse.Text   // The text with the most call sites to merge into.
+	Merge []codeparse.Text // The texts suggested to be replaced by Keep.
+}
+
+// findNearDuplicates groups the near-duplicate texts of scan by domain.
+func findNearDuplicates(scan *codeparse.Scan) []NearDuplicates {
+	byDomain := make(map[*codeparse.Domain][]codeparse.Text)
+	for text := range scan.Texts.SeqRead() {
+		byDomain[text.Domain] = append(byDomain[text.Domain], text)
+	}
+
+	var groups []NearDuplicates
+	for _, texts := range byDomain {
+		groups = append(groups, groupNearDuplicates(texts)...)
+	}
+	slices.SortFunc(groups, func(a, b NearDuplicates) int {
+		return comparePositions(a.Keep, b.Keep)
+	})
+	return groups
+}
+
+// groupNearDuplicates returns the groups of near-duplicates among texts
+// of a single domain.
+func groupNearDuplicates(texts []codeparse.Text) []NearDuplicates {
+	type entry struct {
+		text       codeparse.Text
+		normalized string
+		length     int
+	}
+	entries := make([]entry, len(texts))
+	for i, t := range texts {
+		n := normalizeTIK(t.TIK)
+		entries[i] = entry{text: t, normalized: n, length: utf8.RuneCountInString(n)}
+	}
+	// Sorting by length allows stopping early once lengths differ too much.
+	slices.SortFunc(entries, func(a, b entry) int {
+		return cmp.Or(
+			cmp.Compare(a.length, b.length),
+			cmp.Compare(a.normalized, b.normalized),
+			comparePositions(a.text, b.text),
+		)
+	})
+
+	// Union-find over the entries.
+	parent := make([]int, len(entries))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i, a := range entries {
+		for j := i + 1; j < len(entries); j++ {
+			b := entries[j]
+			if a.normalized != b.normalized {
+				if b.length-a.length > int(float64(b.length)*nearDuplicateRatio) {
+					break // All remaining entries are even longer.
+				}
+				if a.length < nearDuplicateMinLen ||
+					!levenshtein.Similar(a.normalized, b.normalized, nearDuplicateRatio) {
+					continue
+				}
+			}
+			parent[find(j)] = find(i)
+		}
+	}
+
+	members := make(map[int][]codeparse.Text)
+	for i, e := range entries {
+		root := find(i)
+		members[root] = append(members[root], e.text)
+	}
+	var groups []NearDuplicates
+	for _, m := range members {
+		if len(m) < 2 {
+			continue
+		}
+		// Suggest keeping the text used most, then the one declared first.
+		slices.SortFunc(m, func(a, b codeparse.Text) int {
+			return cmp.Or(
+				cmp.Compare(len(b.Positions), len(a.Positions)),
+				comparePositions(a, b),
+			)
+		})
+		groups = append(groups, NearDuplicates{Keep: m[0], Merge: m[1:]})
+	}
+	return groups
+}
+
+// normalizeTIK returns the lower-cased literal text of t with collapsed
+// whitespace and without trailing punctuation. Placeholders are replaced
+// by their class so that, for example, {text} and {name} are equal.
+// The context is kept as is since it deliberately disambiguates TIKs.
+func normalizeTIK(t tik.TIK) string {
+	var b strings.Builder
+	for _, token := range t.Tokens {
+		switch token.Type {
+		case tik.TokenTypeLiteral:
+			b.WriteString(strings.ToLower(token.String(t.Raw)))
+		case tik.TokenTypeText, tik.TokenTypeTextWithGender:
+			b.WriteString("{text}")
+		case tik.TokenTypeInteger, tik.TokenTypeNumber:
+			b.WriteString("{number}")
+		case tik.TokenTypeDateFull, tik.TokenTypeDateLong,
+			tik.TokenTypeDateMedium, tik.TokenTypeDateShort:
+			b.WriteString("{date}")
+		case tik.TokenTypeTimeFull, tik.TokenTypeTimeLong,
+			tik.TokenTypeTimeMedium, tik.TokenTypeTimeShort:
+			b.WriteString("{time}")
+		default:
+			b.WriteString(token.String(t.Raw))
+		}
+	}
+	s := strings.Join(strings.Fields(b.String()), " ")
+	return strings.TrimRightFunc(s, func(r rune) bool {
+		// Keep the closing braces of placeholders.
+		return r != '}' && (unicode.IsPunct(r) || unicode.IsSpace(r))
+	})
+}
+
+// comparePositions orders texts by the position of their first call site.
+func comparePositions(a, b codeparse.Text) int {
+	return cmp.Or(
+		cmp.Compare(a.Position.Filename, b.Position.Filename),
+		cmp.Compare(a.Position.Line, b.Position.Line),
+		cmp.Compare(a.Position.Column, b.Position.Column),
+	)
+}
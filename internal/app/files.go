@@ -0,0 +1,157 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/romshark/toki/internal/diff"
+	"github.com/romshark/toki/internal/watch"
+)
+
+// fileWriter abstracts all file system mutations of the generator
+// allowing them to be redirected to memory in check mode.
+type fileWriter interface {
+	MkdirAll(dir string) error
+	WriteFile(path string, data []byte) error
+	Remove(path string) error
+}
+
+// osFileWriter writes directly to the file system.
+type osFileWriter struct{}
+
+var _ fileWriter = osFileWriter{}
+
+func (osFileWriter) MkdirAll(dir string) error { return os.MkdirAll(dir, 0o755) }
+
+func (osFileWriter) WriteFile(path string, data []byte) error {
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (osFileWriter) Remove(path string) error { return os.Remove(path) }
+
+// recordingFileWriter writes directly to the file system and records
+// the hashes of all files written and the files removed.
+type recordingFileWriter struct {
+	osFileWriter
+	written map[string]uint64   // Absolute path -> contents hash.
+	removed map[string]struct{} // Absolute paths.
+}
+
+var _ fileWriter = new(recordingFileWriter)
+
+func newRecordingFileWriter() *recordingFileWriter {
+	return &recordingFileWriter{
+		written: make(map[string]uint64),
+		removed: make(map[string]struct{}),
+	}
+}
+
+func (r *recordingFileWriter) WriteFile(path string, data []byte) error {
+	if err := r.osFileWriter.WriteFile(path, data); err != nil {
+		return err
+	}
+	path = absPath(path)
+	delete(r.removed, path)
+	r.written[path] = watch.Hash(data)
+	return nil
+}
+
+func (r *recordingFileWriter) Remove(path string) error {
+	if err := r.osFileWriter.Remove(path); err != nil {
+		return err
+	}
+	path = absPath(path)
+	delete(r.written, path)
+	r.removed[path] = struct{}{}
+	return nil
+}
+
+// absPath returns the absolute path of path or path itself if it can't be determined.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// memFileWriter records all file system mutations in memory
+// without ever touching the file system.
+type memFileWriter struct {
+	files map[string][]byte // Absolute path -> contents (nil if removed).
+	order []string          // Paths in order of first mutation.
+}
+
+var _ fileWriter = new(memFileWriter)
+
+func newMemFileWriter() *memFileWriter {
+	return &memFileWriter{files: make(map[string][]byte)}
+}
+
+func (m *memFileWriter) MkdirAll(string) error { return nil }
+
+func (m *memFileWriter) WriteFile(path string, data []byte) error {
+	// Always store a non-nil slice to distinguish empty files from removed files.
+	m.set(path, append([]byte{}, data...))
+	return nil
+}
+
+func (m *memFileWriter) Remove(path string) error {
+	m.set(path, nil)
+	return nil
+}
+
+func (m *memFileWriter) set(path string, data []byte) {
+	path = absPath(path)
+	if _, ok := m.files[path]; !ok {
+		m.order = append(m.order, path)
+	}
+	m.files[path] = data
+}
+
+// Diff compares the recorded state against the file system and writes a unified
+// diff for every file that differs to w. Returns the paths of all differing files.
+func (m *memFileWriter) Diff(w io.Writer) (stale []string, err error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("getting working directory: %w", err)
+	}
+	for _, path := range m.order {
+		current, err := os.ReadFile(path)
+		exists := err == nil
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return stale, fmt.Errorf("reading %q: %w", path, err)
+		}
+
+		expected, keep := m.files[path]
+		keep = keep && expected != nil
+		if exists == keep && bytes.Equal(current, expected) {
+			continue // Up to date.
+		}
+
+		name := path
+		if rel, err := filepath.Rel(wd, path); err == nil {
+			name = rel
+		}
+		nameOld, nameNew := "a/"+name, "b/"+name
+		if !exists {
+			nameOld = "/dev/null"
+		}
+		if !keep {
+			nameNew = "/dev/null"
+		}
+		stale = append(stale, name)
+		d := diff.Unified(nameOld, nameNew, current, expected)
+		if d == nil {
+			// Either side is an empty file, contents are equal.
+			d = fmt.Appendf(nil, "--- %s\n+++ %s\n", nameOld, nameNew)
+		}
+		if _, err := w.Write(d); err != nil {
+			return stale, fmt.Errorf("writing diff: %w", err)
+		}
+	}
+	return stale, nil
+}
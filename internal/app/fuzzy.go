@@ -0,0 +1,139 @@
+package app
+
+import (
+	"cmp"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/romshark/toki/internal/arb"
+	"github.com/romshark/toki/internal/codeparse"
+	"github.com/romshark/toki/internal/levenshtein"
+	"github.com/romshark/toki/internal/log"
+
+	"github.com/romshark/tik/tik-go"
+)
+
+// fuzzyMatchRatio is the maximum edit distance between an old and a new TIK
+// relative to the length of the longer one for them to be considered similar.
+const fuzzyMatchRatio = 0.25
+
+// fuzzyMatch is a new text that is similar to a removed one.
+type fuzzyMatch struct {
+	Text   codeparse.Text
+	OldID  string
+	OldTIK string
+	dist   int
+}
+
+// findFuzzyMatches matches new texts with similar removed messages.
+// A removed message is only considered similar if it belongs to the same domain,
+// has the same placeholder shape and its TIK is within the edit distance limit.
+// Each removed message is matched at most once, closest matches first.
+func (g *Generate) findFuzzyMatches(
+	scan *codeparse.Scan, newTexts []codeparse.Text, removedIDs []string,
+) []fuzzyMatch {
+	var candidates []fuzzyMatch
+	for _, oldID := range removedIDs {
+		oldTIK, ok := scan.BundleTIKs[oldID]
+		if !ok {
+			continue // The original TIK is unknown.
+		}
+		old, err := g.tikParser.Parse(oldTIK)
+		if err != nil {
+			continue
+		}
+		oldShape := placeholderShape(old)
+		for _, text := range newTexts {
+			if codeparse.HashMessage(g.hasher, text.Domain, oldTIK) != oldID {
+				continue // Different domain.
+			}
+			if !slices.Equal(oldShape, placeholderShape(text.TIK)) {
+				continue
+			}
+			if !levenshtein.Similar(oldTIK, text.TIK.Raw, fuzzyMatchRatio) {
+				continue
+			}
+			candidates = append(candidates, fuzzyMatch{
+				Text:   text,
+				OldID:  oldID,
+				OldTIK: oldTIK,
+				dist:   levenshtein.Distance(oldTIK, text.TIK.Raw),
+			})
+		}
+	}
+
+	// Sort for deterministic results.
+	slices.SortFunc(candidates, func(a, b fuzzyMatch) int {
+		return cmp.Or(
+			cmp.Compare(a.dist, b.dist),
+			cmp.Compare(a.Text.IDHash, b.Text.IDHash),
+			cmp.Compare(a.OldID, b.OldID),
+		)
+	})
+
+	matched := make(map[string]struct{}, len(candidates))
+	var matches []fuzzyMatch
+	for _, c := range candidates {
+		if _, ok := matched[c.OldID]; ok {
+			continue
+		}
+		if _, ok := matched[c.Text.IDHash]; ok {
+			continue
+		}
+		matched[c.OldID], matched[c.Text.IDHash] = struct{}{}, struct{}{}
+		matches = append(matches, c)
+	}
+	return matches
+}
+
+// carryOverFuzzy copies the translations of removed messages to the new similar
+// messages in all non-native catalogs and marks them with AttrFuzzy.
+func (g *Generate) carryOverFuzzy(scan *codeparse.Scan, matches []fuzzyMatch) error {
+	for _, m := range matches {
+		newMsg, err := g.newARBMsg(scan.DefaultLocale, m.Text)
+		if err != nil {
+			return err
+		}
+		setMsgPosition(&newMsg, m.Text)
+		for catalog := range scan.Catalogs.SeqRead() {
+			if catalog.ARB.Locale == scan.DefaultLocale {
+				continue // The native message is always generated from the TIK.
+			}
+			old, ok := catalog.ARB.Messages[m.OldID]
+			if !ok || old.ICUMessage == "" {
+				continue // Nothing to carry over.
+			}
+			log.Verbose("carry over translation",
+				slog.String("catalog", catalog.ARB.Locale.String()),
+				slog.String("from", m.OldID),
+				slog.String("to", newMsg.ID))
+			attrs := maps.Clone(newMsg.CustomAttributes)
+			if attrs == nil {
+				attrs = make(map[string]any, 1)
+			}
+			attrs[codeparse.AttrFuzzy] = m.OldTIK
+			catalog.ARB.Messages[newMsg.ID] = arb.Message{
+				ID:               newMsg.ID,
+				ICUMessage:       old.ICUMessage,
+				ICUMessageTokens: old.ICUMessageTokens,
+				Description:      newMsg.Description,
+				Comment:          newMsg.Comment,
+				Type:             newMsg.Type,
+				Context:          newMsg.Context,
+				Placeholders:     newMsg.Placeholders,
+				CustomAttributes: attrs,
+			}
+			catalog.MessagesIncomplete.Add(1)
+		}
+	}
+	return nil
+}
+
+func placeholderShape(t tik.TIK) []tik.TokenType {
+	var s []tik.TokenType
+	for _, p := range t.Placeholders() {
+		s = append(s, p.Type)
+	}
+	return s
+}
@@ -11,6 +11,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -35,10 +36,13 @@ type Generate struct {
 	icuTokenizer     *icumsg.Tokenizer
 	tikParser        *tik.Parser
 	tikICUTranslator *tik.ICUTranslator
+
+	// written records all files written by the generator if non-nil (watch mode).
+	written *recordingFileWriter
 }
 
 func (g *Generate) Run(
-	osArgs, env []string, lintOnly bool, stderr io.Writer, now time.Time,
+	osArgs, env []string, lintOnly bool, stderr, stdout io.Writer, now time.Time,
 ) (result Result) {
 	result.Start = now
 	conf, err := config.ParseCLIArgsGenerate(osArgs)
@@ -58,76 +62,246 @@ func (g *Generate) Run(
 	}
 
 	if lintOnly {
+		if conf.Check {
+			result.Err = fmt.Errorf("%w: -check is not supported by lint",
+				ErrInvalidCLIArgs)
+			return result
+		}
 		log.Info("linting mode")
 	}
 
-	if !lintOnly {
-		// Create bundle package directory if it doesn't exist yet.
-		if err := prepareBundlePackageDir(conf.BundlePkgPath); err != nil {
-			result.Err = err
+	if conf.Watch {
+		switch {
+		case lintOnly:
+			result.Err = fmt.Errorf("%w: -watch is not supported by lint",
+				ErrInvalidCLIArgs)
+			return result
+		case conf.Check:
+			result.Err = fmt.Errorf("%w: -watch can't be combined with -check",
+				ErrInvalidCLIArgs)
+			return result
+		case conf.Format != "":
+			result.Err = fmt.Errorf("%w: -watch can't be combined with -format",
+				ErrInvalidCLIArgs)
 			return result
 		}
+		return g.watch(conf, env, stdout, now)
 	}
 
-	// Read/create head.txt.
-	createIfNotExist := !lintOnly
-	headTxt, err := readOrCreateHeadTxt(conf, createIfNotExist)
+	if conf.Format == "" {
+		return g.run(conf, env, lintOnly, stdout, now)
+	}
+
+	// Findings are written to stdout, write diffs in check mode to stderr instead.
+	result = g.run(conf, env, lintOnly, stderr, now)
+	if err := writeReport(stdout, conf.Format, result); err != nil {
+		result.Err = errors.Join(result.Err, fmt.Errorf("writing findings: %w", err))
+	}
+	return result
+}
+
+// generate runs the generator pipeline once for all bundles.
+func (g *Generate) generate(
+	conf *config.ConfigGenerate, env []string, lintOnly bool, stdout io.Writer,
+	now time.Time,
+) (result Result) {
+	result.Start = now
+	result.Config = conf
+
+	// In check mode all changes are recorded in memory and compared against
+	// the file system in the end instead of being written.
+	var out fileWriter = osFileWriter{}
+	if g.written != nil {
+		out = g.written
+	}
+	var checkFiles *memFileWriter
+	if conf.Check {
+		log.Info("check mode")
+		checkFiles = newMemFileWriter()
+		out = checkFiles
+	}
+
+	modPaths, trimPathBase, err := scanModules(
+		env, conf.ModPath, conf.Workspace, conf.TrimPath,
+	)
 	if err != nil {
 		result.Err = err
 		return result
 	}
 
 	if !lintOnly {
-		// Generate a .tokidomain.yml file at the module root if one doesn't exist.
-		if err := generateTokiDomainFile(conf.ModPath); err != nil {
+		// Generate a .tokidomain.yml file at the root of the module owning
+		// the bundles if one doesn't exist.
+		if err := generateTokiDomainFile(out, conf.ModPath); err != nil {
 			result.Err = fmt.Errorf("generating %s: %w",
 				codeparse.DomainFileName, err)
 			return result
 		}
+	}
+
+	bundlePkgPaths := make([]string, len(conf.Bundles))
+	headTxts := make([][]string, len(conf.Bundles))
+	for i, bundle := range conf.Bundles {
+		bundlePkgPaths[i] = bundle.PkgPath
+		headTxts[i], err = prepareBundle(out, conf, bundle.PkgPath, lintOnly)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+	}
+
+	parser := codeparse.NewParser(
+		g.hasher, g.tikParser, g.tikICUTranslator, newScanCache(env),
+	)
 
-		mainBundleFile := filepath.Join(
-			conf.ModPath, conf.BundlePkgPath, MainBundleFileGo,
+	// Parse source code and bundles.
+	builds := make([]codeparse.Build, len(conf.Builds))
+	for i, b := range conf.Builds {
+		builds[i] = codeparse.Build(b)
+	}
+	scans, err := parser.Parse(env, modPaths, bundlePkgPaths, builds, trimPathBase)
+	if err != nil {
+		if len(scans) == 1 {
+			result.Scan = scans[0]
+		}
+		result.Err = fmt.Errorf("%w: %w", ErrAnalyzingSource, err)
+		return result
+	}
+	for i, bundle := range conf.Bundles {
+		err := parser.ParseTemplates(scans[i], conf.ModPath,
+			slices.Concat(conf.Templates, bundle.Templates), trimPathBase)
+		if err != nil {
+			result.Err = fmt.Errorf("%w: %w", ErrAnalyzingSource, err)
+			return result
+		}
+	}
+
+	if len(conf.Bundles) == 1 {
+		result = g.generateBundle(
+			conf, conf.Bundles[0], scans[0], headTxts[0], out, lintOnly, now,
 		)
-		if _, err := os.Stat(mainBundleFile); errors.Is(err, os.ErrNotExist) {
-			// Require the locale parameter in this case.
-			if conf.Locale == language.Und {
-				result.Err = ErrMissingLocaleParam
-				return result
+	} else {
+		for i, bundle := range conf.Bundles {
+			log.Verbose("generating bundle", slog.String("bundle", bundle.PkgPath))
+			r := g.generateBundle(
+				conf, bundle, scans[i], headTxts[i], out, lintOnly, now,
+			)
+			result.Bundles = append(result.Bundles, r)
+			if result.Err == nil && r.Err != nil {
+				result.Err = fmt.Errorf("bundle %q: %w", bundle.PkgPath, r.Err)
 			}
+		}
+	}
+	if result.Err != nil {
+		return result
+	}
 
-			scan := codeparse.NewScan(conf.Locale, Version)
-			// Need to generate an empty bundle package first.
-			// Otherwise if the bundle existed and was imported before, later got removed
-			// and then toki generate was rerun it will first generate an incorrect bundle
-			// codeparse will be missing method receiver type information on first scan.
-			if err := generateGoBundle(conf.BundlePkgPath, scan, headTxt); err != nil {
-				result.Err = err
-				return result
-			}
-		} else if err != nil {
-			result.Err = fmt.Errorf(
-				"checking main bundle file %q: %w",
-				mainBundleFile, err,
-			)
+	if checkFiles != nil {
+		result.StaleFiles, err = checkFiles.Diff(stdout)
+		if err != nil {
+			result.Err = fmt.Errorf("comparing generated files: %w", err)
+			return result
+		}
+		if len(result.StaleFiles) > 0 {
+			result.Err = ErrBundleStale
 			return result
 		}
 	}
 
-	parser := codeparse.NewParser(g.hasher, g.tikParser, g.tikICUTranslator)
+	for _, scan := range scans {
+		if !conf.QuietMode && conf.VerboseMode {
+			// Report incomplete messages in verbose mode.
+			for catalog := range scan.Catalogs.SeqRead() {
+				for _, msg := range catalog.ARB.Messages {
+					errs := icu.AnalysisReport(catalog.ARB.Locale,
+						msg.ICUMessage, msg.ICUMessageTokens,
+						codeparse.ICUSelectOptions)
+					for _, errMsg := range errs {
+						log.Warn(errMsg,
+							slog.String("id", msg.ID))
+					}
+				}
+			}
+		}
 
-	// Parse source code and bundle.
-	result.Scan, result.Err = parser.Parse(
-		env, conf.ModPath, conf.BundlePkgPath, conf.TrimPath,
-	)
-	if result.Err != nil {
-		result.Err = fmt.Errorf("%w: %w", ErrAnalyzingSource, result.Err)
-		return result
+		if conf.RequireComplete {
+			for catalog := range scan.Catalogs.SeqRead() {
+				if catalog.MessagesIncomplete.Load() > 0 {
+					result.Err = ErrBundleIncomplete
+					return result
+				}
+			}
+		}
 	}
-	scan := result.Scan
+
+	return result
+}
+
+// prepareBundle creates the bundle package directory, its head.txt and
+// an empty bundle if they don't exist yet, unless lintOnly.
+// Returns the lines of head.txt.
+func prepareBundle(
+	out fileWriter, conf *config.ConfigGenerate, bundlePkgPath string, lintOnly bool,
+) (headTxt []string, err error) {
+	if !lintOnly {
+		// Create bundle package directory if it doesn't exist yet.
+		if err := prepareBundlePackageDir(out, bundlePkgPath); err != nil {
+			return nil, err
+		}
+	}
+
+	// Read/create head.txt.
+	createIfNotExist := !lintOnly
+	headTxt, err = readOrCreateHeadTxt(out, bundlePkgPath, createIfNotExist)
+	if err != nil {
+		return nil, err
+	}
+
+	if lintOnly {
+		return headTxt, nil
+	}
+
+	mainBundleFile := filepath.Join(bundlePkgPath, MainBundleFileGo)
+	if _, err := os.Stat(mainBundleFile); errors.Is(err, os.ErrNotExist) {
+		// Require the locale parameter in this case.
+		if conf.Locale == language.Und {
+			return nil, ErrMissingLocaleParam
+		}
+
+		scan := codeparse.NewScan(conf.Locale, Version)
+		// Need to generate an empty bundle package first.
+		// Otherwise if the bundle existed and was imported before, later got removed
+		// and then toki generate was rerun it will first generate an incorrect bundle
+		// codeparse will be missing method receiver type information on first scan.
+		err := generateGoBundle(out, conf, bundlePkgPath, scan, headTxt)
+		if err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf(
+			"checking main bundle file %q: %w",
+			mainBundleFile, err,
+		)
+	}
+	return headTxt, nil
+}
+
+// generateBundle updates the catalogs and the generated code of a single bundle
+// from its scan.
+func (g *Generate) generateBundle(
+	conf *config.ConfigGenerate, bundle config.ConfigBundle, scan *codeparse.Scan,
+	headTxt []string, out fileWriter, lintOnly bool, now time.Time,
+) (result Result) {
+	result.Start = now
+	result.Config = conf
+	result.Bundle = bundle.PkgPath
+	result.Scan = scan
 	if scan.SourceErrors.Len() > 0 {
 		result.Err = ErrSourceErrors
 		return result
 	}
+	result.NearDuplicates = findNearDuplicates(scan)
+	result.PartialTexts = findPartialTexts(scan)
 
 	if conf.Locale != language.Und {
 		// Locale parameter provided.
@@ -136,7 +310,7 @@ func (g *Generate) Run(
 			// but the locale parameter doesn't match its default locale.
 			result.Err = fmt.Errorf("parameter -l (%q) must either match "+
 				"DefaultLocale (%q) in package %q or not be set at all",
-				conf.Locale.String(), scan.DefaultLocale.String(), conf.BundlePkgPath)
+				conf.Locale.String(), scan.DefaultLocale.String(), bundle.PkgPath)
 			return result
 		}
 		scan.DefaultLocale = conf.Locale
@@ -162,7 +336,7 @@ func (g *Generate) Run(
 
 	if nativeARB == nil {
 		nativeARBFilePath = filepath.Join(
-			conf.BundlePkgPath,
+			bundle.PkgPath,
 			gengo.FileNameWithLocale(scan.DefaultLocale, "catalog", ".arb"),
 		)
 		nativeARBFilePath, err := filepath.Abs(nativeARBFilePath)
@@ -185,11 +359,24 @@ func (g *Generate) Run(
 		scan.Catalogs.Append(nativeCatalog)
 	}
 
+	archives, err := readObsoleteArchives(bundle.PkgPath, scan)
+	if err != nil {
+		result.Err = fmt.Errorf("%w: %w", ErrAnalyzingSource, err)
+		return result
+	}
+
 	// Check for new messages.
 	for id, index := range scan.TextIndexByID.SeqRead() {
 		if _, ok := nativeARB.Messages[id]; !ok {
 			// Text not in native catalog.
 			text := scan.Texts.At(index)
+			if restoreObsolete(scan, archives, text) {
+				log.Verbose("restored TIK",
+					slog.String("position", log.FmtPos(text.Position)),
+					slog.String("id", id))
+				result.RestoredTexts = append(result.RestoredTexts, text)
+				continue
+			}
 			result.NewTexts = append(result.NewTexts, text)
 			newMsg, err := g.newARBMsg(scan.DefaultLocale, text)
 			if err != nil {
@@ -199,6 +386,7 @@ func (g *Generate) Run(
 			log.Verbose("new TIK",
 				slog.String("position", log.FmtPos(text.Position)),
 				slog.String("id", newMsg.ID))
+			setMsgPosition(&newMsg, text)
 			nativeARB.Messages[newMsg.ID] = newMsg
 			if incomplete := codeparse.IsMsgIncomplete(
 				scan, nativeARB, nativeARBFilePath, &newMsg,
@@ -208,12 +396,11 @@ func (g *Generate) Run(
 			continue
 		}
 		for catalog := range scan.Catalogs.SeqRead() {
-			// Check in all other catalogs.
-			if catalog.ARB.Locale == scan.DefaultLocale {
-				// Skip native catalog. It was already handled above.
-				continue
-			}
-			if _, ok := catalog.ARB.Messages[id]; ok {
+			// Keep the position of the first call site and the metadata up to date.
+			if msg, ok := catalog.ARB.Messages[id]; ok {
+				setMsgPosition(&msg, scan.Texts.At(index))
+				setMsgMetadata(&msg, scan.Texts.At(index))
+				catalog.ARB.Messages[id] = msg
 				continue
 			}
 			log.Warn("message missing in catalog",
@@ -239,34 +426,69 @@ func (g *Generate) Run(
 		}
 	}
 
-	// Delete unused messages.
+	var removedIDs []string
 	for id := range nativeARB.Messages {
-		index, ok := scan.TextIndexByID.Get(id)
-		if ok {
-			continue
+		if _, ok := scan.TextIndexByID.Get(id); !ok {
+			removedIDs = append(removedIDs, id)
 		}
+	}
+
+	// Preserve translations of edited TIKs.
+	matches := g.findFuzzyMatches(scan, result.NewTexts, removedIDs)
+	if err := g.carryOverFuzzy(scan, matches); err != nil {
+		result.Err = fmt.Errorf("%w: %w", ErrAnalyzingSource, err)
+		return result
+	}
+	for _, m := range matches {
+		result.FuzzyTexts = append(result.FuzzyTexts, m.Text)
+	}
+
+	// List all call sites in every message of every catalog.
+	for id, index := range scan.TextIndexByID.SeqRead() {
 		text := scan.Texts.At(index)
+		for catalog := range scan.Catalogs.SeqRead() {
+			if msg, ok := catalog.ARB.Messages[id]; ok {
+				setMsgLocations(&msg, text)
+				catalog.ARB.Messages[id] = msg
+			}
+		}
+	}
+
+	// Move unused messages to the obsolete archives.
+	for _, id := range removedIDs {
+		text := codeparse.Text{IDHash: id}
+		if raw, ok := scan.BundleTIKs[id]; ok {
+			text.TIK, _ = g.tikParser.Parse(raw)
+		}
 		log.Verbose("unused TIK",
-			slog.String("id", text.IDHash),
-			slog.String("pos", log.FmtPos(text.Position)),
+			slog.String("id", id),
+			slog.String("tik", text.TIK.Raw),
 		)
-		delete(nativeARB.Messages, id)
-		// Delete message in all other catalogs too.
+		position, _ := nativeARB.Messages[id].CustomAttributes[codeparse.AttrPosition].(string)
 		for c := range scan.Catalogs.Seq() {
-			delete(c.ARB.Messages, id)
+			if msg, ok := c.ARB.Messages[id]; ok {
+				archives.Archive(c.ARB.Locale, msg, now, position)
+				delete(c.ARB.Messages, id)
+			}
 		}
 		result.RemovedTexts = append(result.RemovedTexts, text)
 	}
 
 	// (Re-)Generate .arb files.
 	if !lintOnly {
-		if err := writeARBFiles(conf.BundlePkgPath, scan.Catalogs); err != nil {
+		if err := writeARBFiles(out, bundle.PkgPath, scan.Catalogs); err != nil {
+			result.Err = err
+			return result
+		}
+
+		if err := archives.Write(out); err != nil {
 			result.Err = err
 			return result
 		}
 
 		if err := writeMissingARBFilesAndUpdateCatalogs(
-			now, conf.BundlePkgPath, scan.DefaultLocale, conf.Translations,
+			out, now, bundle.PkgPath, scan.DefaultLocale,
+			append(slices.Clone(conf.Translations), bundle.Translations...),
 			nativeARB, scan.Catalogs,
 		); err != nil {
 			result.Err = err
@@ -275,47 +497,32 @@ func (g *Generate) Run(
 
 		if scan.TokiVersion == "" || scan.TokiVersion != Version {
 			// Clear generated files on version mismatch.
-			if err := deleteAllTokiGeneratedFiles(conf.BundlePkgPath); err != nil {
+			err := deleteAllTokiGeneratedFiles(out, bundle.PkgPath)
+			if err != nil {
 				result.Err = fmt.Errorf("removing sources of existing bundle: %w", err)
 				return result
 			}
 		}
 
 		// Generate go bundle.
-		if err := generateGoBundle(conf.BundlePkgPath, scan, headTxt); err != nil {
+		err := generateGoBundle(out, conf, bundle.PkgPath, scan, headTxt)
+		if err != nil {
 			result.Err = err
 			return result
 		}
 	}
 
-	if !conf.QuietMode && conf.VerboseMode {
-		// Report incomplete messages in verbose mode.
-		for catalog := range scan.Catalogs.SeqRead() {
-			for _, msg := range catalog.ARB.Messages {
-				errs := icu.AnalysisReport(catalog.ARB.Locale,
-					msg.ICUMessage, msg.ICUMessageTokens,
-					codeparse.ICUSelectOptions)
-				for _, errMsg := range errs {
-					log.Warn(errMsg,
-						slog.String("id", msg.ID))
-				}
-			}
-		}
-	}
-
-	if conf.RequireComplete && result.Err == nil {
-		for catalog := range scan.Catalogs.SeqRead() {
-			if catalog.MessagesIncomplete.Load() > 0 {
-				result.Err = ErrBundleIncomplete
-				return result
-			}
-		}
+	// Translations exceeding their maximum length are reported
+	// after writing the catalogs to not block the generator.
+	checkMaxLengths(scan, nativeARB)
+	if scan.SourceErrors.Len() > 0 {
+		result.Err = ErrSourceErrors
 	}
 
 	return result
 }
 
-func deleteAllTokiGeneratedFiles(dir string) error {
+func deleteAllTokiGeneratedFiles(out fileWriter, dir string) error {
 	const generatedHeader = "// Generated by github.com/romshark/toki. DO NOT EDIT"
 	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -334,7 +541,7 @@ func deleteAllTokiGeneratedFiles(dir string) error {
 		scanner := bufio.NewScanner(file)
 		if scanner.Scan() && strings.HasPrefix(scanner.Text(), generatedHeader) {
 			_ = file.Close()
-			if err := os.Remove(path); err != nil {
+			if err := out.Remove(path); err != nil {
 				return fmt.Errorf("deleting file: %w", err)
 			}
 		}
@@ -342,39 +549,30 @@ func deleteAllTokiGeneratedFiles(dir string) error {
 	})
 }
 
-func writeARBFiles(bundlePkgPath string, catalogs *sync.Slice[*codeparse.Catalog]) error {
+func writeARBFiles(
+	out fileWriter, bundlePkgPath string, catalogs *sync.Slice[*codeparse.Catalog],
+) error {
 	for catalog := range catalogs.SeqRead() {
 		locale := catalog.ARB.Locale
 		name := gengo.FileNameWithLocale(locale, "catalog", ".arb")
 		filePath := filepath.Join(bundlePkgPath, name)
 
-		err := func() error {
-			f, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
-			if err != nil {
-				return fmt.Errorf("opening .arb catalog (%q): %w",
-					locale.String(), err)
-			}
-			defer func() {
-				if err := f.Close(); err != nil {
-					log.Error("closing catalog .arb file", err)
-				}
-			}()
-			setARBMetadata(catalog.ARB)
-			if err := arb.Encode(f, catalog.ARB, "\t"); err != nil {
-				return fmt.Errorf("encoding .arb catalog (%q): %w",
-					locale.String(), err)
-			}
-			return nil
-		}()
-		if err != nil {
-			return err
+		var buf bytes.Buffer
+		setARBMetadata(catalog.ARB)
+		if err := arb.Encode(&buf, catalog.ARB, "\t"); err != nil {
+			return fmt.Errorf("encoding .arb catalog (%q): %w",
+				locale.String(), err)
+		}
+		if err := out.WriteFile(filePath, buf.Bytes()); err != nil {
+			return fmt.Errorf("writing .arb catalog (%q): %w",
+				locale.String(), err)
 		}
 	}
 	return nil
 }
 
 func writeMissingARBFilesAndUpdateCatalogs(
-	now time.Time, bundlePkgPath string, defaultLocale language.Tag,
+	out fileWriter, now time.Time, bundlePkgPath string, defaultLocale language.Tag,
 	translations []language.Tag, nativeARB *arb.File,
 	catalogs *sync.Slice[*codeparse.Catalog],
 ) error {
@@ -398,44 +596,32 @@ func writeMissingARBFilesAndUpdateCatalogs(
 			panic(err)
 		}
 
-		err = func() error {
-			f, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
-			if err != nil {
-				return fmt.Errorf("opening new .arb catalog (%q): %w",
-					locale.String(), err)
-			}
-			defer func() {
-				if err := f.Close(); err != nil {
-					log.Error("closing new catalog .arb file", err)
-				}
-			}()
-
-			newFile := nativeARB.Copy(func(m *arb.Message) {
-				// Reset the message, don't copy from native.
-				m.ICUMessage = ""
-				m.ICUMessageTokens = nil
-			})
-			newFile.Locale = locale
-			newFile.LastModified = now
-			setARBMetadata(newFile)
-			if err := arb.Encode(f, newFile, "\t"); err != nil {
-				return fmt.Errorf("encoding new .arb catalog (%q): %w",
-					locale.String(), err)
-			}
+		newFile := nativeARB.Copy(func(m *arb.Message) {
+			// Reset the message, don't copy from native.
+			m.ICUMessage = ""
+			m.ICUMessageTokens = nil
+		})
+		newFile.Locale = locale
+		newFile.LastModified = now
+		setARBMetadata(newFile)
 
-			// Add a new catalog.
-			newCatalog := &codeparse.Catalog{
-				ARB:         newFile,
-				ARBFilePath: filePath,
-			}
-			newCatalog.MessagesIncomplete.Store(int64(len(newFile.Messages)))
-			catalogs.Append(newCatalog)
+		var buf bytes.Buffer
+		if err := arb.Encode(&buf, newFile, "\t"); err != nil {
+			return fmt.Errorf("encoding new .arb catalog (%q): %w",
+				locale.String(), err)
+		}
+		if err := out.WriteFile(filePath, buf.Bytes()); err != nil {
+			return fmt.Errorf("writing new .arb catalog (%q): %w",
+				locale.String(), err)
+		}
 
-			return nil
-		}()
-		if err != nil {
-			return err
+		// Add a new catalog.
+		newCatalog := &codeparse.Catalog{
+			ARB:         newFile,
+			ARBFilePath: filePath,
 		}
+		newCatalog.MessagesIncomplete.Store(int64(len(newFile.Messages)))
+		catalogs.Append(newCatalog)
 	}
 	return nil
 }
@@ -450,7 +636,7 @@ func setARBMetadata(f *arb.File) {
 
 // generateTokiDomainFile creates a .tokidomain.yml file at modPath if one does not
 // already exist. The domain name is derived from the Go module name.
-func generateTokiDomainFile(modPath string) error {
+func generateTokiDomainFile(out fileWriter, modPath string) error {
 	domainFilePath := filepath.Join(modPath, codeparse.DomainFileName)
 	if _, err := os.Stat(domainFilePath); err == nil {
 		return nil // Already exists, don't overwrite.
@@ -482,37 +668,33 @@ func generateTokiDomainFile(modPath string) error {
 			"description: \"\"\n",
 		name,
 	)
-	return os.WriteFile(domainFilePath, []byte(content), 0o644)
+	return out.WriteFile(domainFilePath, []byte(content))
 }
 
-func prepareBundlePackageDir(bundlePkgPath string) error {
+func prepareBundlePackageDir(out fileWriter, bundlePkgPath string) error {
 	if _, err := os.Stat(bundlePkgPath); errors.Is(err, os.ErrNotExist) {
 		log.Verbose("create new bundle package", slog.String("path", bundlePkgPath))
 	}
-	if err := os.MkdirAll(bundlePkgPath, 0o755); err != nil {
+	if err := out.MkdirAll(bundlePkgPath); err != nil {
 		return fmt.Errorf("mkdir: bundle package path: %w", err)
 	}
 	return nil
 }
 
 func generateGoBundle(
-	bundlePkgPath string, scan *codeparse.Scan, headTxtLines []string,
+	out fileWriter, conf *config.ConfigGenerate, bundlePkgPath string,
+	scan *codeparse.Scan, headTxtLines []string,
 ) error {
 	pkgName := filepath.Base(bundlePkgPath)
 
 	// Make sure the bundle package dir exists.
-	if err := os.MkdirAll(bundlePkgPath, 0o755); err != nil {
+	if err := out.MkdirAll(bundlePkgPath); err != nil {
 		return err
 	}
 
 	bundleGoFilePath := filepath.Join(bundlePkgPath, MainBundleFileGo)
-	writer := gengo.NewWriter(Version, scan)
+	writer := gengo.NewWriter(Version, scan, conf.Fallbacks)
 	{
-		f, err := os.OpenFile(bundleGoFilePath, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0o644)
-		if err != nil {
-			return err
-		}
-
 		// Generate the main Go bundle file.
 		var buf bytes.Buffer
 		writer.WritePackageBundle(&buf, pkgName, headTxtLines)
@@ -521,7 +703,7 @@ func generateGoBundle(
 		if err != nil {
 			return fmt.Errorf("formatting bundle: %w", err)
 		}
-		if _, err := f.Write(formatted); err != nil {
+		if err := out.WriteFile(bundleGoFilePath, formatted); err != nil {
 			return fmt.Errorf("writing formatted bundle code to file: %w", err)
 		}
 	}
@@ -531,10 +713,6 @@ func generateGoBundle(
 		locale := catalog.ARB.Locale
 		fileName := gengo.FileNameWithLocale(locale, "catalog", "_gen.go")
 		filePath := filepath.Join(bundlePkgPath, fileName)
-		f, err := os.OpenFile(filePath, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0o644)
-		if err != nil {
-			return err
-		}
 
 		var buf bytes.Buffer
 		writer.WritePackageCatalog(&buf, locale, pkgName, headTxtLines)
@@ -543,19 +721,40 @@ func generateGoBundle(
 		if err != nil {
 			return fmt.Errorf("formatting package catalog: %w", err)
 		}
-		if _, err := f.Write(formatted); err != nil {
+		if err := out.WriteFile(filePath, formatted); err != nil {
 			return fmt.Errorf("writing formatted code to file: %w", err)
 		}
 	}
+
+	// Generate or remove the interpreter Go file.
+	interpreterFilePath := filepath.Join(bundlePkgPath, InterpreterFileGo)
+	if !conf.Interpreter {
+		if _, err := os.Stat(interpreterFilePath); err == nil {
+			if err := out.Remove(interpreterFilePath); err != nil {
+				return fmt.Errorf("removing interpreter: %w", err)
+			}
+		}
+		return nil
+	}
+	var buf bytes.Buffer
+	writer.WritePackageInterpreter(&buf, pkgName, headTxtLines)
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("formatting interpreter: %w", err)
+	}
+	if err := out.WriteFile(interpreterFilePath, formatted); err != nil {
+		return fmt.Errorf("writing formatted interpreter code to file: %w", err)
+	}
 	return nil
 }
 
 // readOrCreateHeadTxt reads the head.txt file if it exists, otherwise creates it.
 func readOrCreateHeadTxt(
-	conf *config.ConfigGenerate,
+	out fileWriter,
+	bundlePkgPath string,
 	createIfNotExist bool,
 ) ([]string, error) {
-	headFilePath := filepath.Join(conf.BundlePkgPath, "head.txt")
+	headFilePath := filepath.Join(bundlePkgPath, "head.txt")
 	if fc, err := os.ReadFile(headFilePath); errors.Is(err, os.ErrNotExist) {
 		if !createIfNotExist {
 			log.Warn("head.txt not found")
@@ -563,13 +762,9 @@ func readOrCreateHeadTxt(
 		}
 
 		log.Warn("head.txt not found, creating a new one")
-		f, err := os.Create(headFilePath)
-		if err != nil {
+		if err := out.WriteFile(headFilePath, nil); err != nil {
 			return nil, fmt.Errorf("creating head.txt file: %w", err)
 		}
-		if err := f.Close(); err != nil {
-			log.Error("closing head.txt file", err)
-		}
 	} else if err != nil {
 		return nil, fmt.Errorf("reading head.txt: %w", err)
 	} else if len(fc) > 0 {
@@ -635,6 +830,9 @@ func (g *Generate) newARBMsg(
 			pl.Type = arb.PlaceholderNum
 			pl.Example = "USD(4.00)"
 		}
+		if example, ok := text.Metadata.Examples[name]; ok {
+			pl.Example = example
+		}
 		placeholders[name] = pl
 	}
 
@@ -643,13 +841,47 @@ func (g *Generate) newARBMsg(
 		return arb.Message{ID: msg.ID}, err
 	}
 
-	return arb.Message{
+	msg = arb.Message{
 		ID:               msg.ID,
 		ICUMessage:       icuMsg,
 		ICUMessageTokens: icuTokens,
 		Description:      description,
 		Type:             arb.MessageTypeText,
-		Context:          text.Context(),
 		Placeholders:     placeholders,
-	}, nil
+	}
+	setMsgMetadata(&msg, text)
+	return msg, nil
+}
+
+// setMsgMetadata sets the context and the custom attributes of msg
+// declared by the comment directives of text.
+func setMsgMetadata(msg *arb.Message, text codeparse.Text) {
+	msg.Context = text.Context()
+	if c := text.Metadata.Context; c != "" {
+		if msg.Context != "" {
+			msg.Context += "; "
+		}
+		msg.Context += c
+	}
+	for name, example := range text.Metadata.Examples {
+		if pl, ok := msg.Placeholders[name]; ok {
+			pl.Example = example
+			msg.Placeholders[name] = pl
+		}
+	}
+
+	setAttr := func(name string, value any, set bool) {
+		if !set {
+			delete(msg.CustomAttributes, name)
+			return
+		}
+		if msg.CustomAttributes == nil {
+			msg.CustomAttributes = make(map[string]any, 1)
+		}
+		msg.CustomAttributes[name] = value
+	}
+	setAttr(codeparse.AttrMaxLen, text.Metadata.MaxLen, text.Metadata.MaxLen > 0)
+	setAttr(codeparse.AttrMaxWidth, text.Metadata.MaxWidth, text.Metadata.MaxWidth > 0)
+	setAttr(codeparse.AttrScreenshots, text.Metadata.Screenshots,
+		len(text.Metadata.Screenshots) > 0)
 }
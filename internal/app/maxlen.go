@@ -0,0 +1,149 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/romshark/toki/internal/arb"
+	"github.com/romshark/toki/internal/codeparse"
+	"github.com/romshark/toki/internal/icu"
+
+	"github.com/romshark/icumsg"
+	"github.com/romshark/tik/tik-go"
+)
+
+var (
+	ErrMaxLenExceeded  = errors.New("maximum length exceeded")
+	ErrMaxLenNoExample = errors.New("missing example for maximum length")
+)
+
+// checkMaxLengths appends a source error to scan for every message of every
+// catalog that may render longer than the maximum length or display width
+// declared for it in nativeARB.
+// Texts with a limit must declare an example for every text placeholder
+// since their length can't be known otherwise.
+func checkMaxLengths(scan *codeparse.Scan, nativeARB *arb.File) {
+	unchecked := make(map[string]struct{}) // IDs of texts lacking examples.
+	for text := range scan.Texts.SeqRead() {
+		if text.Metadata.MaxLen == 0 && text.Metadata.MaxWidth == 0 {
+			continue
+		}
+		for i, placeholder := range text.TIK.Placeholders() {
+			name := fmt.Sprintf("var%d", i)
+			if _, ok := text.Metadata.Examples[name]; ok ||
+				(placeholder.Type != tik.TokenTypeText &&
+					placeholder.Type != tik.TokenTypeTextWithGender) {
+				continue
+			}
+			unchecked[text.IDHash] = struct{}{}
+			scan.SourceErrors.Append(codeparse.SourceError{
+				Position: text.Position,
+				Err: fmt.Errorf("%w: %s: add %s %s=...",
+					ErrMaxLenNoExample, text.IDHash,
+					codeparse.DirectiveExample, name),
+			})
+		}
+	}
+
+	for catalog := range scan.Catalogs.SeqRead() {
+		var data []byte // Contents of the catalog file to find message lines in.
+		for _, id := range slices.Sorted(maps.Keys(catalog.ARB.Messages)) {
+			if _, ok := unchecked[id]; ok {
+				continue
+			}
+			native := nativeARB.Messages[id]
+			maxLen := intAttr(native.CustomAttributes[codeparse.AttrMaxLen])
+			maxWidth := intAttr(native.CustomAttributes[codeparse.AttrMaxWidth])
+			msg := catalog.ARB.Messages[id]
+			if (maxLen == 0 && maxWidth == 0) || msg.ICUMessage == "" {
+				continue
+			}
+
+			arg := worstCaseArg(native.Placeholders)
+			for _, limit := range []struct {
+				max     int
+				measure func(string) int
+				unit    string
+			}{
+				{maxLen, icu.Chars, "characters"},
+				{maxWidth, icu.Width, "cells wide"},
+			} {
+				if limit.max == 0 {
+					continue
+				}
+				n := icu.MaxLength(msg.ICUMessage, msg.ICUMessageTokens, limit.measure, arg)
+				if n <= limit.max {
+					continue
+				}
+				if data == nil {
+					// The line is unknown (0) if the catalog wasn't written yet.
+					data, _ = os.ReadFile(catalog.ARBFilePath)
+				}
+				scan.SourceErrors.Append(codeparse.SourceError{
+					Position: token.Position{
+						Filename: scan.TrimPath(catalog.ARBFilePath),
+						Line:     lineOf(data, []byte(`"`+id+`"`)),
+					},
+					Err: fmt.Errorf("%w: %s (%s) may be up to %d %s, maximum is %d",
+						ErrMaxLenExceeded, id, catalog.ARB.Locale.String(),
+						n, limit.unit, limit.max),
+				})
+			}
+		}
+	}
+}
+
+// intAttr returns the integer value of a custom ARB attribute
+// or 0 if it's not a number.
+func intAttr(v any) int {
+	switch v := v.(type) {
+	case int:
+		return v
+	case float64: // Decoded from JSON.
+		return int(v)
+	}
+	return 0
+}
+
+// worstCaseArg returns the longest expected values of message arguments.
+// Text arguments are substituted by the example of their placeholder
+// (see //toki:example) which checkMaxLengths requires.
+func worstCaseArg(placeholders map[string]arb.Placeholder) icu.FnArg {
+	return func(name string, argType, argStyle icumsg.TokenType) string {
+		switch argType {
+		case icumsg.TokenTypeArgTypeNumber:
+			switch argStyle {
+			case icumsg.TokenTypeArgStyleInteger:
+				return "999,999"
+			case icumsg.TokenTypeArgStyleSkeleton: // Currency.
+				return "USD 999,999.99"
+			}
+			return "999,999.99"
+		case icumsg.TokenTypeArgTypeDate:
+			switch argStyle {
+			case icumsg.TokenTypeArgStyleFull:
+				return "Wednesday, September 30, 2026"
+			case icumsg.TokenTypeArgStyleLong:
+				return "September 30, 2026"
+			case icumsg.TokenTypeArgStyleMedium:
+				return "Sep 30, 2026"
+			}
+			return "09/30/2026"
+		case icumsg.TokenTypeArgTypeTime:
+			switch argStyle {
+			case icumsg.TokenTypeArgStyleFull:
+				return "11:59:59 PM Coordinated Universal Time"
+			case icumsg.TokenTypeArgStyleLong:
+				return "11:59:59 PM UTC"
+			case icumsg.TokenTypeArgStyleMedium:
+				return "11:59:59 PM"
+			}
+			return "11:59 PM"
+		}
+		return placeholders[name].Example
+	}
+}
@@ -0,0 +1,208 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/romshark/toki/internal/arb"
+	"github.com/romshark/toki/internal/codeparse"
+	"github.com/romshark/toki/internal/gengo"
+	"github.com/romshark/toki/internal/log"
+
+	"golang.org/x/text/language"
+)
+
+// attrObsoleteSince is the custom ARB message attribute holding the time
+// a message was moved to the obsolete archive.
+const attrObsoleteSince = "x-toki-obsolete-since"
+
+// obsoleteARBFileName returns the file name of the obsolete archive for locale.
+func obsoleteARBFileName(locale language.Tag) string {
+	return gengo.FileNameWithLocale(locale, "catalog", ".obsolete.arb")
+}
+
+// obsoleteArchives holds the messages no longer used in the source code
+// by catalog locale. Archived messages are restored as soon as they're used again.
+type obsoleteArchives struct {
+	bundlePkgPath string
+	byLocale      map[language.Tag]*arb.File
+	changed       map[language.Tag]bool
+}
+
+// readObsoleteArchives reads the obsolete archives of all catalogs.
+func readObsoleteArchives(
+	bundlePkgPath string, scan *codeparse.Scan,
+) (*obsoleteArchives, error) {
+	a := &obsoleteArchives{
+		bundlePkgPath: bundlePkgPath,
+		byLocale:      make(map[language.Tag]*arb.File),
+		changed:       make(map[language.Tag]bool),
+	}
+	dec := arb.NewDecoder()
+	for catalog := range scan.Catalogs.SeqRead() {
+		locale := catalog.ARB.Locale
+		f, err := readObsoleteARBFile(dec, bundlePkgPath, locale)
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			a.byLocale[locale] = f
+		}
+	}
+	return a, nil
+}
+
+// readObsoleteARBFile returns nil and no error if the archive doesn't exist.
+func readObsoleteARBFile(
+	dec *arb.Decoder, bundlePkgPath string, locale language.Tag,
+) (*arb.File, error) {
+	name := obsoleteARBFileName(locale)
+	f, err := os.Open(filepath.Join(bundlePkgPath, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening obsolete archive: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	file, err := dec.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing obsolete archive %s: %w", name, err)
+	}
+	if file.Locale != locale {
+		return nil, fmt.Errorf("locale in ARB file (%s) differs from file name (%s): %s",
+			file.Locale.String(), locale.String(), name)
+	}
+	return file, nil
+}
+
+// Archive moves msg to the archive of locale.
+func (a *obsoleteArchives) Archive(
+	locale language.Tag, msg arb.Message, now time.Time, position string,
+) {
+	f := a.byLocale[locale]
+	if f == nil {
+		f = &arb.File{Locale: locale, Messages: make(map[string]arb.Message)}
+		a.byLocale[locale] = f
+	}
+	attrs := make(map[string]any, len(msg.CustomAttributes)+2)
+	for k, v := range msg.CustomAttributes {
+		attrs[k] = v
+	}
+	attrs[attrObsoleteSince] = now.Format(time.RFC3339)
+	if position != "" {
+		attrs[codeparse.AttrPosition] = position
+	}
+	msg.CustomAttributes = attrs
+	f.Messages[msg.ID] = msg
+	f.LastModified = now
+	a.changed[locale] = true
+}
+
+// Restore removes the message with id from the archive of locale and returns it.
+func (a *obsoleteArchives) Restore(locale language.Tag, id string) (arb.Message, bool) {
+	f := a.byLocale[locale]
+	if f == nil {
+		return arb.Message{}, false
+	}
+	msg, ok := f.Messages[id]
+	if !ok {
+		return arb.Message{}, false
+	}
+	delete(f.Messages, id)
+	delete(msg.CustomAttributes, attrObsoleteSince)
+	a.changed[locale] = true
+	return msg, true
+}
+
+// Write writes all changed archives. Archives left empty are removed.
+func (a *obsoleteArchives) Write(out fileWriter) error {
+	for locale, f := range a.byLocale {
+		if !a.changed[locale] {
+			continue
+		}
+		path := filepath.Join(a.bundlePkgPath, obsoleteARBFileName(locale))
+		if err := writeObsoleteARBFile(out, path, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeObsoleteARBFile(out fileWriter, path string, f *arb.File) error {
+	if len(f.Messages) == 0 {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		log.Verbose("remove empty obsolete archive", slog.String("file", path))
+		if err := out.Remove(path); err != nil {
+			return fmt.Errorf("removing obsolete archive: %w", err)
+		}
+		return nil
+	}
+	setARBMetadata(f)
+	var buf bytes.Buffer
+	if err := arb.Encode(&buf, f, "\t"); err != nil {
+		return fmt.Errorf("encoding obsolete archive (%q): %w", f.Locale.String(), err)
+	}
+	if err := out.WriteFile(path, buf.Bytes()); err != nil {
+		return fmt.Errorf("writing obsolete archive (%q): %w", f.Locale.String(), err)
+	}
+	return nil
+}
+
+// restoreObsolete restores the archived messages of text in all catalogs.
+// Returns false if text isn't archived in the native catalog's archive.
+func restoreObsolete(
+	scan *codeparse.Scan, archives *obsoleteArchives, text codeparse.Text,
+) bool {
+	nativeMsg, ok := archives.Restore(scan.DefaultLocale, text.IDHash)
+	if !ok {
+		return false
+	}
+	setMsgPosition(&nativeMsg, text)
+	for catalog := range scan.Catalogs.SeqRead() {
+		msg := nativeMsg
+		if catalog.ARB.Locale != scan.DefaultLocale {
+			if msg, ok = archives.Restore(catalog.ARB.Locale, text.IDHash); !ok {
+				continue
+			}
+		}
+		catalog.ARB.Messages[msg.ID] = msg
+		if codeparse.IsMsgIncomplete(scan, catalog.ARB, catalog.ARBFilePath, &msg) {
+			catalog.MessagesIncomplete.Add(1)
+		}
+	}
+	return true
+}
+
+// setMsgPosition sets the source position attribute of msg to the position of text.
+func setMsgPosition(msg *arb.Message, text codeparse.Text) {
+	pos := codeparse.FmtPosition(text.Position)
+	if pos == "" {
+		return
+	}
+	if msg.CustomAttributes == nil {
+		msg.CustomAttributes = make(map[string]any, 1)
+	}
+	msg.CustomAttributes[codeparse.AttrPosition] = pos
+}
+
+// setMsgLocations sets the locations attribute of msg to the positions
+// of all call sites of text.
+func setMsgLocations(msg *arb.Message, text codeparse.Text) {
+	locations := text.Locations()
+	if len(locations) == 0 {
+		return
+	}
+	if msg.CustomAttributes == nil {
+		msg.CustomAttributes = make(map[string]any, 1)
+	}
+	msg.CustomAttributes[codeparse.AttrLocations] = locations
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/romshark/toki/internal/arb"
+	"github.com/romshark/toki/internal/config"
+	"github.com/romshark/toki/internal/log"
+
+	"golang.org/x/text/language"
+)
+
+// Prune implements the command `toki prune`.
+type Prune struct{}
+
+func (p *Prune) Run(osArgs []string, stderr io.Writer, now time.Time) error {
+	conf, err := config.ParseCLIArgsPrune(osArgs)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCLIArgs, err)
+	}
+
+	log.SetWriter(stderr, false)
+
+	bundleDir := filepath.Join(conf.ModPath, conf.BundlePkgPath)
+	entries, err := os.ReadDir(bundleDir)
+	if err != nil {
+		return fmt.Errorf("reading bundle package directory: %w", err)
+	}
+
+	cutoff := now.Add(-conf.OlderThan)
+	dec := arb.NewDecoder()
+	total := 0
+	for _, e := range entries {
+		name := e.Name()
+		withoutExt, ok := strings.CutSuffix(name, ".obsolete.arb")
+		if e.IsDir() || !ok {
+			continue
+		}
+		withoutPrefix, ok := strings.CutPrefix(withoutExt, "catalog_")
+		locale, err := language.Parse(withoutPrefix)
+		if !ok || err != nil {
+			log.Verbose("ignoring unknown obsolete archive", slog.String("file", name))
+			continue
+		}
+
+		f, err := readObsoleteARBFile(dec, bundleDir, locale)
+		if err != nil {
+			return err
+		}
+
+		pruned := 0
+		for id, msg := range f.Messages {
+			s, _ := msg.CustomAttributes[attrObsoleteSince].(string)
+			since, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				log.Warn("ignoring archived message with invalid "+attrObsoleteSince,
+					slog.String("file", name), slog.String("id", id))
+				continue
+			}
+			if since.Before(cutoff) {
+				delete(f.Messages, id)
+				pruned++
+			}
+		}
+		if pruned == 0 {
+			continue
+		}
+
+		log.Info("pruned obsolete messages",
+			slog.String("file", name), slog.Int("pruned", pruned))
+		path := filepath.Join(bundleDir, name)
+		if err := writeObsoleteARBFile(osFileWriter{}, path, f); err != nil {
+			return err
+		}
+		total += pruned
+	}
+
+	log.Info("finished", slog.Int("pruned", total))
+	return nil
+}
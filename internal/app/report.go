@@ -0,0 +1,160 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/romshark/toki/internal/codeparse"
+	"github.com/romshark/toki/internal/config"
+	"github.com/romshark/toki/internal/icu"
+	"github.com/romshark/toki/internal/report"
+)
+
+// writeReport writes all findings of r to w in the given format.
+func writeReport(w io.Writer, format string, r Result) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+	findings := collectFindings(r, wd)
+	report.Sort(findings)
+	switch format {
+	case config.FormatSARIF:
+		return report.WriteSARIF(w, Version, findings)
+	case config.FormatGitHub:
+		return report.WriteGitHub(w, findings)
+	case config.FormatCheckstyle:
+		return report.WriteCheckstyle(w, findings)
+	}
+	return fmt.Errorf("unsupported format: %q", format)
+}
+
+// collectFindings returns the findings of r and all of its modules and bundles.
+// File paths are relative to wd.
+func collectFindings(r Result, wd string) (findings []report.Finding) {
+	for _, f := range r.StaleFiles {
+		findings = append(findings, report.Finding{
+			Rule:     report.RuleStaleFile,
+			Severity: report.SeverityError,
+			Message:  "file is out of date, rerun `toki generate`",
+			File:     f,
+		})
+	}
+	if len(r.Modules) > 0 || len(r.Bundles) > 0 {
+		for _, sub := range slices.Concat(r.Modules, r.Bundles) {
+			findings = append(findings, collectFindings(sub, wd)...)
+		}
+		return findings
+	}
+
+	if arbErr := (*codeparse.ARBError)(nil); errors.As(r.Err, &arbErr) {
+		findings = append(findings, report.Finding{
+			Rule:     report.RuleARBInvalid,
+			Severity: report.SeverityError,
+			Message:  arbErr.Err.Error(),
+			File:     relPath(wd, arbErr.File),
+		})
+	}
+	if r.Scan == nil || r.Config == nil {
+		return findings
+	}
+
+	for e := range r.Scan.SourceErrors.SeqRead() {
+		rule := report.RuleSourceError
+		switch {
+		case errors.Is(e.Err, codeparse.ErrTIKCollision):
+			rule = report.RuleTIKCollision
+		case errors.Is(e.Err, codeparse.ErrUnsupportedSelectOption):
+			rule = report.RuleICUUnsupportedSelectOption
+		case errors.Is(e.Err, ErrMaxLenExceeded):
+			rule = report.RuleMaxLength
+		}
+		findings = append(findings, report.Finding{
+			Rule:     rule,
+			Severity: report.SeverityError,
+			Message:  e.Err.Error(),
+			File:     relPath(wd, r.Scan.UntrimPath(e.Filename)),
+			Line:     e.Line,
+			Col:      e.Column,
+		})
+	}
+
+	for _, g := range r.NearDuplicates {
+		for _, t := range g.Merge {
+			for _, pos := range t.Positions {
+				findings = append(findings, report.Finding{
+					Rule:     report.RuleNearDuplicate,
+					Severity: report.SeverityWarning,
+					Message: fmt.Sprintf("%q is a near-duplicate of %q (%s), consider merging",
+						t.TIK.Raw, g.Keep.TIK.Raw, codeparse.FmtPosition(g.Keep.Position)),
+					File: relPath(wd, r.Scan.UntrimPath(pos.Filename)),
+					Line: pos.Line,
+					Col:  pos.Column,
+				})
+			}
+		}
+	}
+
+	for _, t := range r.PartialTexts {
+		for _, pos := range t.Positions {
+			findings = append(findings, report.Finding{
+				Rule:     report.RuleBuildPartial,
+				Severity: report.SeverityWarning,
+				Message: fmt.Sprintf("%q is only found in build configurations: %s",
+					t.TIK.Raw, strings.Join(t.Builds, ", ")),
+				File: relPath(wd, r.Scan.UntrimPath(pos.Filename)),
+				Line: pos.Line,
+				Col:  pos.Column,
+			})
+		}
+	}
+
+	// Incomplete messages fail the command only if completeness is required.
+	severity := report.SeverityWarning
+	if r.Config.RequireComplete {
+		severity = report.SeverityError
+	}
+	for catalog := range r.Scan.Catalogs.SeqRead() {
+		data, _ := os.ReadFile(catalog.ARBFilePath)
+		for _, id := range slices.Sorted(maps.Keys(catalog.ARB.Messages)) {
+			msg := catalog.ARB.Messages[id]
+			for _, m := range icu.AnalysisReport(catalog.ARB.Locale,
+				msg.ICUMessage, msg.ICUMessageTokens,
+				codeparse.ICUSelectOptions) {
+				findings = append(findings, report.Finding{
+					Rule:     report.RuleICUIncomplete,
+					Severity: severity,
+					Message:  fmt.Sprintf("%s: %s", id, m),
+					File:     relPath(wd, catalog.ARBFilePath),
+					Line:     lineOf(data, []byte(`"`+id+`"`)),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// relPath returns path relative to wd if possible.
+func relPath(wd, path string) string {
+	if rel, err := filepath.Rel(wd, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// lineOf returns the 1-based line number of the first occurrence of sub in data
+// or 0 if there is none.
+func lineOf(data, sub []byte) int {
+	i := bytes.Index(data, sub)
+	if i == -1 {
+		return 0
+	}
+	return bytes.Count(data[:i], []byte("\n")) + 1
+}
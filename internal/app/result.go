@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/romshark/toki/internal/codeparse"
@@ -15,11 +17,29 @@ import (
 
 type Result struct {
 	Config       *config.ConfigGenerate
+	Bundle       string // Bundle package path.
 	Start        time.Time
 	Scan         *codeparse.Scan
 	NewTexts     []codeparse.Text
 	RemovedTexts []codeparse.Text
-	Err          error
+	FuzzyTexts   []codeparse.Text // New texts with carried over translations.
+
+	// RestoredTexts holds the texts restored from the obsolete archives.
+	RestoredTexts []codeparse.Text
+	StaleFiles    []string // Files out of date in check mode.
+
+	// NearDuplicates holds the groups of TIKs that likely mean the same.
+	NearDuplicates []NearDuplicates
+
+	// PartialTexts holds the texts found in only some build configurations.
+	PartialTexts []codeparse.Text
+
+	// Modules holds the results of every module in the per-module workspace mode.
+	Modules []Result
+
+	// Bundles holds the results of every bundle if there's more than one.
+	Bundles []Result
+	Err     error
 }
 
 type ResultJSONCatalog struct {
@@ -33,17 +53,36 @@ type ResultJSONSourceError struct {
 	Col   int    `json:"col"`
 }
 
+type ResultJSONNearDuplicate struct {
+	TIK       string   `json:"tik"`
+	Locations []string `json:"locations"`
+	MergeInto string   `json:"merge-into"`
+}
+
+type ResultJSONPartialTIK struct {
+	TIK       string   `json:"tik"`
+	Locations []string `json:"locations"`
+	Builds    []string `json:"builds"`
+}
+
 type ResultJSON struct {
-	Error          string                  `json:"error,omitempty"`
-	StringCalls    int64                   `json:"string-calls"`
-	WriteCalls     int64                   `json:"write-calls"`
-	TIKs           int                     `json:"tiks"`
-	TIKsUnique     int                     `json:"tiks-unique"`
-	TIKsNew        int                     `json:"tiks-new"`
-	FilesTraversed int                     `json:"files-traversed"`
-	SourceErrors   []ResultJSONSourceError `json:"source-errors,omitempty"`
-	TimeMS         int64                   `json:"time-ms"`
-	Catalogs       []ResultJSONCatalog     `json:"catalogs"`
+	Error          string                    `json:"error,omitempty"`
+	StringCalls    int64                     `json:"string-calls"`
+	WriteCalls     int64                     `json:"write-calls"`
+	AppendCalls    int64                     `json:"append-calls"`
+	TIKs           int                       `json:"tiks"`
+	TIKsUnique     int                       `json:"tiks-unique"`
+	TIKsNew        int                       `json:"tiks-new"`
+	TIKsFuzzy      int                       `json:"tiks-fuzzy"`
+	TIKsRestored   int                       `json:"tiks-restored"`
+	FilesTraversed int                       `json:"files-traversed"`
+	PackagesCached int                       `json:"packages-cached"`
+	SourceErrors   []ResultJSONSourceError   `json:"source-errors,omitempty"`
+	StaleFiles     []string                  `json:"stale-files,omitempty"`
+	NearDuplicates []ResultJSONNearDuplicate `json:"near-duplicates,omitempty"`
+	PartialTIKs    []ResultJSONPartialTIK    `json:"partial-tiks,omitempty"`
+	TimeMS         int64                     `json:"time-ms"`
+	Catalogs       []ResultJSONCatalog       `json:"catalogs"`
 }
 
 func (r Result) mustPrintJSON() {
@@ -56,10 +95,15 @@ func (r Result) mustPrintJSON() {
 		Error:          errMsg,
 		StringCalls:    r.Scan.StringCalls.Load(),
 		WriteCalls:     r.Scan.WriteCalls.Load(),
+		AppendCalls:    r.Scan.AppendCalls.Load(),
 		TIKs:           r.Scan.Texts.Len(),
 		TIKsUnique:     r.Scan.TextIndexByID.Len(),
 		TIKsNew:        len(r.NewTexts),
+		TIKsFuzzy:      len(r.FuzzyTexts),
+		TIKsRestored:   len(r.RestoredTexts),
 		FilesTraversed: int(r.Scan.FilesTraversed.Load()),
+		PackagesCached: int(r.Scan.PackagesCached.Load()),
+		StaleFiles:     r.StaleFiles,
 		TimeMS:         time.Since(r.Start).Milliseconds(),
 	}
 	_ = r.Scan.SourceErrors.Access(func(s []codeparse.SourceError) error {
@@ -74,6 +118,22 @@ func (r Result) mustPrintJSON() {
 		}
 		return nil
 	})
+	for _, g := range r.NearDuplicates {
+		for _, t := range g.Merge {
+			data.NearDuplicates = append(data.NearDuplicates, ResultJSONNearDuplicate{
+				TIK:       t.TIK.Raw,
+				Locations: t.Locations(),
+				MergeInto: g.Keep.TIK.Raw,
+			})
+		}
+	}
+	for _, t := range r.PartialTexts {
+		data.PartialTIKs = append(data.PartialTIKs, ResultJSONPartialTIK{
+			TIK:       t.TIK.Raw,
+			Locations: t.Locations(),
+			Builds:    t.Builds,
+		})
+	}
 	_ = r.Scan.Catalogs.Access(func(s []*codeparse.Catalog) error {
 		data.Catalogs = make([]ResultJSONCatalog, len(s))
 		for i, c := range s {
@@ -91,7 +151,41 @@ func (r Result) mustPrintJSON() {
 	}
 }
 
+// leaves returns the results of all modules and bundles or r itself
+// if it has neither.
+func (r Result) leaves() []Result {
+	if len(r.Modules) == 0 && len(r.Bundles) == 0 {
+		return []Result{r}
+	}
+	var l []Result
+	for _, sub := range slices.Concat(r.Modules, r.Bundles) {
+		l = append(l, sub.leaves()...)
+	}
+	return l
+}
+
 func (r Result) Print() {
+	if len(r.Modules) > 0 {
+		for _, m := range r.Modules {
+			log.Info("module", slog.String("dir", m.Config.ModPath))
+			m.Print()
+		}
+		return
+	}
+	if len(r.Bundles) > 0 {
+		for _, b := range r.Bundles {
+			log.Info("bundle", slog.String("path", b.Bundle))
+			b.Print()
+		}
+		if r.Err != nil && len(r.StaleFiles) > 0 {
+			log.Error(r.Err.Error(), nil)
+			log.Error("stale files", nil, slog.Int("total", len(r.StaleFiles)))
+			for _, f := range r.StaleFiles {
+				log.Error("stale", nil, slog.String("file", f))
+			}
+		}
+		return
+	}
 	if r.Err != nil {
 		log.Error(r.Err.Error(), nil)
 	}
@@ -114,12 +208,45 @@ func (r Result) Print() {
 			return nil
 		})
 
+		if l := len(r.StaleFiles); l > 0 {
+			log.Error("stale files", nil, slog.Int("total", l))
+			for _, f := range r.StaleFiles {
+				log.Error("stale", nil, slog.String("file", f))
+			}
+		}
+
+		if l := len(r.NearDuplicates); l > 0 {
+			log.Warn("near-duplicate TIKs", slog.Int("groups", l))
+			for _, g := range r.NearDuplicates {
+				for _, t := range g.Merge {
+					log.Warn("near-duplicate",
+						slog.String("tik", t.TIK.Raw),
+						slog.String("pos", log.FmtPos(t.Position)),
+						slog.String("merge-into", g.Keep.TIK.Raw),
+						slog.String("merge-into.pos", log.FmtPos(g.Keep.Position)))
+				}
+			}
+		}
+
+		if l := len(r.PartialTexts); l > 0 {
+			log.Warn("TIKs found in only some build configurations", slog.Int("total", l))
+			for _, t := range r.PartialTexts {
+				log.Warn("partial TIK",
+					slog.String("tik", t.TIK.Raw),
+					slog.String("pos", log.FmtPos(t.Position)),
+					slog.String("builds", strings.Join(t.Builds, " ")))
+			}
+		}
+
 		fields := []any{
 			slog.Int("tiks.total", r.Scan.Texts.Len()),
 			slog.Int("tiks.unique", r.Scan.TextIndexByID.Len()),
 			slog.Int("tiks.new", len(r.NewTexts)),
 			slog.Int("tiks.removed", len(r.RemovedTexts)),
+			slog.Int("tiks.fuzzy", len(r.FuzzyTexts)),
+			slog.Int("tiks.restored", len(r.RestoredTexts)),
 			slog.Int64("scan.files", r.Scan.FilesTraversed.Load()),
+			slog.Int64("scan.cached", r.Scan.PackagesCached.Load()),
 			slog.String("scan.duration", time.Since(r.Start).String()),
 			slog.Int64("catalogs", int64(r.Scan.Catalogs.Len())),
 		}
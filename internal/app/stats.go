@@ -0,0 +1,414 @@
+package app
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/romshark/toki/internal/arb"
+	"github.com/romshark/toki/internal/codeparse"
+	"github.com/romshark/toki/internal/config"
+	"github.com/romshark/toki/internal/log"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/romshark/icumsg"
+	"github.com/romshark/tik/tik-go"
+	"golang.org/x/text/language"
+)
+
+// Stats implements the command `toki stats`.
+type Stats struct {
+	hasher           *xxhash.Digest
+	tikParser        *tik.Parser
+	tikICUTranslator *tik.ICUTranslator
+}
+
+func (s *Stats) Run(osArgs, env []string, stderr, stdout io.Writer) error {
+	conf, err := config.ParseCLIArgsStats(osArgs)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCLIArgs, err)
+	}
+
+	log.SetWriter(stderr, false)
+
+	bundlePkgPaths := make([]string, len(conf.Bundles))
+	for i, b := range conf.Bundles {
+		bundlePkgPaths[i] = filepath.Join(conf.ModPath, b.PkgPath)
+		mainBundleFile := filepath.Join(bundlePkgPaths[i], MainBundleFileGo)
+		switch _, err := os.Stat(mainBundleFile); {
+		case errors.Is(err, os.ErrNotExist):
+			return ErrGenerateBundleFirst
+		case err != nil:
+			return fmt.Errorf("checking main bundle file %q: %w", mainBundleFile, err)
+		}
+	}
+
+	parser := codeparse.NewParser(
+		s.hasher, s.tikParser, s.tikICUTranslator, newScanCache(env),
+	)
+	modPaths, trimPathBase, err := scanModules(env, conf.ModPath, conf.Workspace, true)
+	if err != nil {
+		return err
+	}
+	builds := make([]codeparse.Build, len(conf.Builds))
+	for i, b := range conf.Builds {
+		builds[i] = codeparse.Build(b)
+	}
+	scans, err := parser.Parse(env, modPaths, bundlePkgPaths, builds, trimPathBase)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAnalyzingSource, err)
+	}
+
+	reports := make([]StatsReport, len(conf.Bundles))
+	for i, bundle := range conf.Bundles {
+		scan := scans[i]
+		err := parser.ParseTemplates(scan, conf.ModPath,
+			slices.Concat(conf.Templates, bundle.Templates), trimPathBase)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrAnalyzingSource, err)
+		}
+
+		// Source errors don't prevent reporting since invalid ICU messages
+		// in catalogs are reported as source errors as well.
+		for e := range scan.SourceErrors.SeqRead() {
+			log.Warn("source error",
+				slog.String("pos", log.FmtPos(e.Position)),
+				slog.String("err", e.Err.Error()))
+		}
+
+		reports[i] = newStatsReport(scan, conf.ModPath)
+		reports[i].Bundle = bundle.PkgPath
+	}
+
+	report := reports[0]
+	if len(reports) > 1 {
+		report = StatsReport{Bundles: reports}
+	}
+	switch conf.Format {
+	case config.FormatJSON:
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	case config.FormatMarkdown:
+		err = report.WriteMarkdown(stdout)
+	default:
+		err = report.WriteText(stdout)
+	}
+	if err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	return nil
+}
+
+// StatsCounts holds the message counts of a catalog or a part of it.
+// A message is either complete or counted in exactly one of
+// Incomplete, Empty and InvalidICU.
+type StatsCounts struct {
+	Messages   int `json:"messages"`
+	Incomplete int `json:"incomplete"`
+	Empty      int `json:"empty"` // Includes messages missing in the catalog.
+	InvalidICU int `json:"invalid-icu"`
+}
+
+// Completeness returns the ratio of complete messages (1 if there are no messages).
+func (c StatsCounts) Completeness() float64 {
+	if c.Messages == 0 {
+		return 1
+	}
+	complete := c.Messages - c.Incomplete - c.Empty - c.InvalidICU
+	return float64(complete) / float64(c.Messages)
+}
+
+type StatsLocale struct {
+	Locale string `json:"locale"`
+	StatsCounts
+	Completeness float64 `json:"completeness"`
+}
+
+// StatsGroup is either a domain or a Go package.
+type StatsGroup struct {
+	Name    string        `json:"name"`
+	Locales []StatsLocale `json:"locales"`
+}
+
+type StatsReport struct {
+	Bundle   string        `json:"bundle,omitempty"`
+	Locales  []StatsLocale `json:"locales,omitempty"`
+	Domains  []StatsGroup  `json:"domains,omitempty"`
+	Packages []StatsGroup  `json:"packages,omitempty"`
+
+	// Bundles holds the reports of every bundle if there's more than one.
+	Bundles []StatsReport `json:"bundles,omitempty"`
+}
+
+// statsNoDomain is the group name for texts that don't belong to any domain.
+const statsNoDomain = "-"
+
+// newStatsReport returns the report for scan. Domains are named by their
+// chain of domain names from the root domain, domains of different directories
+// with identical names additionally by their directory relative to modPath.
+func newStatsReport(scan *codeparse.Scan, modPath string) StatsReport {
+	catalogs := make([]*codeparse.Catalog, 0, scan.Catalogs.Len())
+	for c := range scan.Catalogs.SeqRead() {
+		catalogs = append(catalogs, c)
+	}
+	// Native catalog first, then by locale.
+	slices.SortFunc(catalogs, func(a, b *codeparse.Catalog) int {
+		aNative := a.ARB.Locale == scan.DefaultLocale
+		bNative := b.ARB.Locale == scan.DefaultLocale
+		switch {
+		case aNative && !bNative:
+			return -1
+		case !aNative && bNative:
+			return 1
+		}
+		return cmp.Compare(a.ARB.Locale.String(), b.ARB.Locale.String())
+	})
+
+	byLocale := make([]StatsCounts, len(catalogs))
+	byDomain := make(map[string][]StatsCounts) // By directory.
+	domains := make(map[string]*codeparse.Domain)
+	byPackage := make(map[string][]StatsCounts)
+	countsOf := func(m map[string][]StatsCounts, name string) []StatsCounts {
+		c, ok := m[name]
+		if !ok {
+			c = make([]StatsCounts, len(catalogs))
+			m[name] = c
+		}
+		return c
+	}
+
+	for text := range scan.Texts.SeqRead() {
+		var domainDir string
+		if text.Domain != nil {
+			domainDir = text.Domain.Dir
+			domains[domainDir] = text.Domain
+		}
+		countsDomain := countsOf(byDomain, domainDir)
+		countsPackage := countsOf(byPackage, text.Package)
+		for i, c := range catalogs {
+			msg, ok := c.ARB.Messages[text.IDHash]
+			status := statsMsgStatus(c.ARB.Locale, msg, ok)
+			for _, counts := range [...]*StatsCounts{
+				&byLocale[i], &countsDomain[i], &countsPackage[i],
+			} {
+				counts.add(status)
+			}
+		}
+	}
+
+	locales := func(counts []StatsCounts) []StatsLocale {
+		l := make([]StatsLocale, len(counts))
+		for i, c := range counts {
+			l[i] = StatsLocale{
+				Locale:       catalogs[i].ARB.Locale.String(),
+				StatsCounts:  c,
+				Completeness: c.Completeness(),
+			}
+		}
+		return l
+	}
+	groups := func(m map[string][]StatsCounts) []StatsGroup {
+		g := make([]StatsGroup, 0, len(m))
+		for name, counts := range m {
+			g = append(g, StatsGroup{Name: name, Locales: locales(counts)})
+		}
+		slices.SortFunc(g, func(a, b StatsGroup) int { return cmp.Compare(a.Name, b.Name) })
+		return g
+	}
+
+	// Name domains by their name chain and disambiguate identical chains.
+	domainNames := make(map[string]string, len(byDomain))
+	dirsByName := make(map[string][]string)
+	for dir := range byDomain {
+		name := statsNoDomain
+		if d := domains[dir]; d != nil {
+			name = statsDomainName(d)
+		}
+		domainNames[dir] = name
+		dirsByName[name] = append(dirsByName[name], dir)
+	}
+	byDomainName := make(map[string][]StatsCounts, len(byDomain))
+	for dir, counts := range byDomain {
+		name := domainNames[dir]
+		if len(dirsByName[name]) > 1 {
+			rel := dir
+			if abs, err := filepath.Abs(modPath); err == nil {
+				if r, err := filepath.Rel(abs, dir); err == nil {
+					rel = r
+				}
+			}
+			name += " (" + filepath.ToSlash(rel) + ")"
+		}
+		byDomainName[name] = counts
+	}
+
+	return StatsReport{
+		Locales:  locales(byLocale),
+		Domains:  groups(byDomainName),
+		Packages: groups(byPackage),
+	}
+}
+
+// statsDomainName returns the names of the domain chain of d
+// from the root domain down to d joined by slashes.
+func statsDomainName(d *codeparse.Domain) string {
+	var names []string
+	for d := range d.Path() {
+		names = append(names, d.Name)
+	}
+	slices.Reverse(names)
+	return strings.Join(names, "/")
+}
+
+type statsStatus int8
+
+const (
+	statsComplete statsStatus = iota
+	statsIncomplete
+	statsEmpty
+	statsInvalidICU
+)
+
+func (c *StatsCounts) add(s statsStatus) {
+	c.Messages++
+	switch s {
+	case statsIncomplete:
+		c.Incomplete++
+	case statsEmpty:
+		c.Empty++
+	case statsInvalidICU:
+		c.InvalidICU++
+	}
+}
+
+// statsMsgStatus classifies msg. ok must be false if msg is missing in the catalog.
+func statsMsgStatus(locale language.Tag, msg arb.Message, ok bool) statsStatus {
+	if !ok || strings.TrimSpace(msg.ICUMessage) == "" {
+		return statsEmpty
+	}
+	incomplete := msg.CustomAttributes[codeparse.AttrFuzzy] != nil
+	rejected := false
+	_, err := icumsg.Analyze(
+		locale, msg.ICUMessage, msg.ICUMessageTokens,
+		codeparse.ICUSelectOptions,
+		func(int) error { incomplete = true; return nil },
+		func(int, int) error { rejected = true; return nil },
+	)
+	switch {
+	case err != nil || rejected:
+		return statsInvalidICU
+	case incomplete:
+		return statsIncomplete
+	}
+	return statsComplete
+}
+
+// statsTable is a report table independent of the output format.
+type statsTable struct {
+	title  string
+	names  int // Number of leading non-numeric columns.
+	header []string
+	rows   [][]string
+}
+
+func (r StatsReport) tables() []statsTable {
+	if len(r.Bundles) > 0 {
+		var tables []statsTable
+		for _, b := range r.Bundles {
+			for _, t := range b.tables() {
+				t.title = b.Bundle + ": " + t.title
+				tables = append(tables, t)
+			}
+		}
+		return tables
+	}
+
+	header := []string{"Messages", "Incomplete", "Empty", "Invalid ICU", "Completeness"}
+	cells := func(l StatsLocale) []string {
+		return []string{
+			strconv.Itoa(l.Messages),
+			strconv.Itoa(l.Incomplete),
+			strconv.Itoa(l.Empty),
+			strconv.Itoa(l.InvalidICU),
+			fmt.Sprintf("%.2f%%", l.Completeness*100),
+		}
+	}
+	groupTable := func(title, column string, groups []StatsGroup) statsTable {
+		t := statsTable{
+			title:  title,
+			names:  2,
+			header: append([]string{column, "Locale"}, header...),
+		}
+		for _, g := range groups {
+			for _, l := range g.Locales {
+				t.rows = append(t.rows, append([]string{g.Name, l.Locale}, cells(l)...))
+			}
+		}
+		return t
+	}
+
+	locales := statsTable{title: "Locales", names: 1, header: append([]string{"Locale"}, header...)}
+	for _, l := range r.Locales {
+		locales.rows = append(locales.rows, append([]string{l.Locale}, cells(l)...))
+	}
+	return []statsTable{
+		locales,
+		groupTable("Domains", "Domain", r.Domains),
+		groupTable("Packages", "Package", r.Packages),
+	}
+}
+
+// WriteText writes the report as plain text tables.
+func (r StatsReport) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, t := range r.tables() {
+		if i > 0 {
+			_, _ = fmt.Fprintln(tw)
+		}
+		_, _ = fmt.Fprintln(tw, strings.ToUpper(t.title)+":")
+		_, _ = fmt.Fprintln(tw, strings.ToUpper(strings.Join(t.header, "\t")))
+		for _, row := range t.rows {
+			_, _ = fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+	}
+	return tw.Flush()
+}
+
+// WriteMarkdown writes the report as Markdown tables.
+func (r StatsReport) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	for i, t := range r.tables() {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString("## " + t.title + "\n\n")
+		b.WriteString("| " + strings.Join(t.header, " | ") + " |\n")
+		b.WriteByte('|')
+		for i := range t.header {
+			if i < t.names {
+				b.WriteString(" --- |") // Names.
+			} else {
+				b.WriteString(" ---: |") // Numbers.
+			}
+		}
+		b.WriteByte('\n')
+		for _, row := range t.rows {
+			for i := range t.names {
+				row[i] = strings.ReplaceAll(row[i], "|", `\|`)
+			}
+			b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
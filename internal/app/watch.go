@@ -0,0 +1,170 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/romshark/toki/internal/codeparse"
+	"github.com/romshark/toki/internal/config"
+	"github.com/romshark/toki/internal/log"
+	"github.com/romshark/toki/internal/watch"
+)
+
+const (
+	watchInterval = 200 * time.Millisecond
+	watchDebounce = 300 * time.Millisecond
+)
+
+// watch reruns the generator whenever any Go source file, domain file or catalog
+// changes until interrupted.
+func (g *Generate) watch(
+	conf *config.ConfigGenerate, env []string, stdout io.Writer, now time.Time,
+) (result Result) {
+	result.Start = now
+	result.Config = conf
+
+	bundleDirs, err := bundleDirs(conf, env)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	// Watch the whole workspace if the module is part of one.
+	root := conf.ModPath
+	ws, err := findWorkspace(env, conf.ModPath, conf.Workspace)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if ws != nil {
+		root = ws.Dir()
+	}
+	templates, err := templatePatterns(conf)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	w, err := watch.New(root, func(path string) bool {
+		name := filepath.Base(path)
+		if slices.Contains(bundleDirs, filepath.Dir(path)) {
+			// Generated Go files in the bundle package are ignored.
+			return strings.HasPrefix(name, "catalog_") &&
+				strings.HasSuffix(name, ".arb") &&
+				!strings.HasSuffix(name, ".obsolete.arb")
+		}
+		if strings.HasSuffix(name, ".go") || name == codeparse.DomainFileName {
+			return true
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return false
+		}
+		return slices.ContainsFunc(templates, func(pattern string) bool {
+			ok, _ := filepath.Match(pattern, abs)
+			return ok
+		})
+	}, watchInterval, watchDebounce)
+	if err != nil {
+		result.Err = fmt.Errorf("initializing watcher: %w", err)
+		return result
+	}
+	if err := w.Snapshot(); err != nil {
+		result.Err = fmt.Errorf("initializing watcher: %w", err)
+		return result
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	defer func() { g.written = nil }()
+	for {
+		g.written = newRecordingFileWriter()
+		for _, r := range g.run(conf, env, false, stdout, now).leaves() {
+			printWatchResult(r)
+		}
+
+		// Ignore the files written by the generator itself unless they were
+		// changed by anyone else in the meantime.
+		for path, hash := range g.written.written {
+			if err := w.Accept(path, hash); err != nil {
+				result.Err = fmt.Errorf("watching: %w", err)
+				return result
+			}
+		}
+		for path := range g.written.removed {
+			if err := w.AcceptRemove(path); err != nil {
+				result.Err = fmt.Errorf("watching: %w", err)
+				return result
+			}
+		}
+
+		log.Info("watching for changes")
+		changed, err := w.Wait(ctx)
+		if errors.Is(err, context.Canceled) {
+			return result
+		}
+		if err != nil {
+			result.Err = fmt.Errorf("watching: %w", err)
+			return result
+		}
+		for _, path := range changed {
+			if rel, err := filepath.Rel(w.Root, path); err == nil {
+				path = rel
+			}
+			log.Verbose("changed", slog.String("file", path))
+		}
+		log.Info("regenerating", slog.Int("changed", len(changed)))
+		now = time.Now()
+	}
+}
+
+// printWatchResult prints only the changes in TIKs and any errors.
+func printWatchResult(r Result) {
+	if r.Scan != nil {
+		for e := range r.Scan.SourceErrors.SeqRead() {
+			log.Error("source", e.Err, slog.String("pos", log.FmtPos(e.Position)))
+		}
+	}
+	if r.Err != nil && !errors.Is(r.Err, ErrSourceErrors) {
+		log.Error(r.Err.Error(), nil)
+	}
+	for _, t := range r.NewTexts {
+		log.Info("new TIK",
+			slog.String("pos", log.FmtPos(t.Position)),
+			slog.String("tik", t.TIK.Raw))
+	}
+	for _, t := range r.RestoredTexts {
+		log.Info("restored TIK",
+			slog.String("pos", log.FmtPos(t.Position)),
+			slog.String("tik", t.TIK.Raw))
+	}
+	for _, t := range r.RemovedTexts {
+		log.Info("removed TIK",
+			slog.String("id", t.IDHash),
+			slog.String("tik", t.TIK.Raw))
+	}
+}
+
+// templatePatterns returns the absolute template glob patterns of all bundles.
+func templatePatterns(conf *config.ConfigGenerate) ([]string, error) {
+	patterns := slices.Clone(conf.Templates)
+	for _, b := range conf.Bundles {
+		patterns = append(patterns, b.Templates...)
+	}
+	for i, pattern := range patterns {
+		abs, err := filepath.Abs(filepath.Join(conf.ModPath, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("determining template pattern path: %w", err)
+		}
+		patterns[i] = abs
+	}
+	return patterns, nil
+}
@@ -52,18 +52,29 @@ func (g *WebEdit) Run(osArgs, env []string, stderr io.Writer) error {
 			)
 		}
 
-		parser := codeparse.NewParser(g.hasher, g.tikParser, g.tikICUTranslator)
+		parser := codeparse.NewParser(
+			g.hasher, g.tikParser, g.tikICUTranslator, newScanCache(env),
+		)
 
 		// TODO: avoid hardcoding trimpath.
-		scan, err := parser.Parse(env, "./...", conf.BundlePkgPath, false)
+		modPaths, _, err := scanModules(env, ".", conf.Workspace, false)
+		if err != nil {
+			return nil, err
+		}
+		scans, err := parser.Parse(env, modPaths, []string{conf.BundlePkgPath}, nil, "")
+		if err != nil {
+			err = fmt.Errorf("%w: %w", ErrAnalyzingSource, err)
+			return nil, err
+		}
+		err = parser.ParseTemplates(scans[0], ".", conf.Templates, "")
 		if err != nil {
 			err = fmt.Errorf("%w: %w", ErrAnalyzingSource, err)
 			return nil, err
 		}
-		if scan.SourceErrors.Len() > 0 {
+		if scans[0].SourceErrors.Len() > 0 {
 			return nil, ErrSourceErrors
 		}
-		return scan, nil
+		return scans[0], nil
 	})
 	if err := s.Init(); err != nil {
 		return fmt.Errorf("initializing server: %w", err)
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/romshark/toki/internal/codeparse"
+	"github.com/romshark/toki/internal/config"
+	"github.com/romshark/toki/internal/log"
+)
+
+// findWorkspace returns the go.work workspace modPath belongs to.
+// Returns nil if the workspace mode is off or modPath isn't part of a workspace.
+func findWorkspace(env []string, modPath, mode string) (*codeparse.Workspace, error) {
+	if mode == config.WorkspaceOff {
+		return nil, nil
+	}
+	ws, err := codeparse.FindWorkspace(env, modPath)
+	if err != nil {
+		return nil, fmt.Errorf("finding workspace: %w", err)
+	}
+	return ws, nil
+}
+
+// scanModules returns the paths of the modules to scan into the bundle of modPath
+// and the base path source file paths are trimmed by (empty if trimpath is false).
+// In the shared workspace mode these are all modules of the workspace.
+func scanModules(
+	env []string, modPath, mode string, trimpath bool,
+) (modPaths []string, trimPathBase string, err error) {
+	modPaths, trimPathBase = []string{modPath}, modPath
+	if mode == config.WorkspaceShared {
+		ws, err := findWorkspace(env, modPath, mode)
+		if err != nil {
+			return nil, "", err
+		}
+		if ws != nil {
+			modPaths, trimPathBase = ws.Modules, ws.Dir()
+		}
+	}
+	if !trimpath {
+		trimPathBase = ""
+	}
+	return modPaths, trimPathBase, nil
+}
+
+// run runs the generator pipeline once, either for the module or for every
+// module of the workspace in the per-module workspace mode.
+func (g *Generate) run(
+	conf *config.ConfigGenerate, env []string, lintOnly bool, stdout io.Writer,
+	now time.Time,
+) (result Result) {
+	if conf.Workspace != config.WorkspacePerModule {
+		return g.generate(conf, env, lintOnly, stdout, now)
+	}
+
+	result.Start = now
+	result.Config = conf
+	ws, err := findWorkspace(env, conf.ModPath, conf.Workspace)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if ws == nil {
+		return g.generate(conf, env, lintOnly, stdout, now)
+	}
+
+	for _, dir := range ws.Modules {
+		log.Verbose("generating module", slog.String("dir", dir))
+		c := *conf
+		c.ModPath = dir
+		c.Bundles = make([]config.ConfigBundle, len(conf.Bundles))
+		for i, b := range conf.Bundles {
+			b.PkgPath = filepath.Join(dir, b.PkgPath)
+			c.Bundles[i] = b
+		}
+		c.Workspace = config.WorkspaceOff
+		r := g.generate(&c, env, lintOnly, stdout, now)
+		result.Modules = append(result.Modules, r)
+		if result.Err == nil && r.Err != nil {
+			result.Err = fmt.Errorf("module %q: %w", dir, r.Err)
+		}
+	}
+	return result
+}
+
+// bundleDirs returns the absolute paths of all bundle package directories
+// written by run.
+func bundleDirs(conf *config.ConfigGenerate, env []string) ([]string, error) {
+	if conf.Workspace == config.WorkspacePerModule {
+		ws, err := findWorkspace(env, conf.ModPath, conf.Workspace)
+		if err != nil {
+			return nil, err
+		}
+		if ws != nil {
+			var dirs []string
+			for _, dir := range ws.Modules {
+				for _, b := range conf.Bundles {
+					dirs = append(dirs, filepath.Join(dir, b.PkgPath))
+				}
+			}
+			return dirs, nil
+		}
+	}
+	dirs := make([]string, len(conf.Bundles))
+	for i, b := range conf.Bundles {
+		dir, err := filepath.Abs(b.PkgPath)
+		if err != nil {
+			return nil, fmt.Errorf("determining bundle package path: %w", err)
+		}
+		dirs[i] = dir
+	}
+	return dirs, nil
+}
@@ -0,0 +1,48 @@
+package codeparse
+
+import "strings"
+
+// Build is a build configuration the source code is scanned in.
+// Empty fields default to the environment, so the zero value
+// is the build configuration of the environment.
+type Build struct {
+	GOOS   string
+	GOARCH string
+	Tags   []string // Build tags such as "integration".
+}
+
+// String returns b formatted as GOOS/GOARCH:tag,tag
+// or "default" if b is the zero value.
+func (b Build) String() string {
+	s := b.GOOS
+	if b.GOARCH != "" {
+		s += "/" + b.GOARCH
+	}
+	if len(b.Tags) > 0 {
+		s += ":" + strings.Join(b.Tags, ",")
+	}
+	if s == "" {
+		return "default"
+	}
+	return s
+}
+
+// env returns env with GOOS and GOARCH overridden by b.
+func (b Build) env(env []string) []string {
+	env = append([]string(nil), env...)
+	if b.GOOS != "" {
+		env = append(env, "GOOS="+b.GOOS)
+	}
+	if b.GOARCH != "" {
+		env = append(env, "GOARCH="+b.GOARCH)
+	}
+	return env
+}
+
+// buildFlags returns the go command flags selecting the build tags of b.
+func (b Build) buildFlags() []string {
+	if len(b.Tags) == 0 {
+		return nil
+	}
+	return []string{"-tags=" + strings.Join(b.Tags, ",")}
+}
@@ -0,0 +1,227 @@
+package codeparse
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// cacheFormat must be changed whenever the format of cache entries changes.
+const cacheFormat = "7"
+
+// Cache is an on-disk cache of the texts extracted from packages.
+// Entries are keyed by the contents of the package files, the files of all
+// its dependencies outside the module cache, the build configuration
+// and the Toki version.
+// A nil *Cache is valid and disables caching.
+type Cache struct {
+	dir         string
+	tokiVersion string
+}
+
+// NewCache creates a new cache storing its entries in dir.
+func NewCache(dir, tokiVersion string) *Cache {
+	return &Cache{dir: dir, tokiVersion: tokiVersion}
+}
+
+// pkgTexts holds all calls of a single package by compiled Go file path.
+type pkgTexts struct {
+	Files map[string][]call `json:"files"`
+}
+
+func (t *pkgTexts) hasErrors() bool {
+	for _, calls := range t.Files {
+		for _, c := range calls {
+			if len(c.errs) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// call is a call to one of the Reader methods.
+type call struct {
+	Reader   string         `json:"reader"` // Reader type of the bundle called.
+	Func     string         `json:"func"`
+	Pos      token.Position `json:"pos"`
+	TIK      string         `json:"tik,omitempty"`
+	Comments []string       `json:"comments,omitempty"`
+
+	errs []SourceError // Calls with errors are never cached.
+}
+
+// isEmpty returns true if c is disabled or has no entries.
+func (c *Cache) isEmpty() bool {
+	if c == nil {
+		return true
+	}
+	d, err := os.Open(c.dir)
+	if err != nil {
+		return true
+	}
+	defer func() { _ = d.Close() }()
+	names, _ := d.Readdirnames(1)
+	return len(names) == 0
+}
+
+func (c *Cache) path(key string) string { return filepath.Join(c.dir, key+".json") }
+
+func (c *Cache) get(key string) (*pkgTexts, bool) {
+	if c == nil || key == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var t pkgTexts
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, false // Treat corrupted entries as missing.
+	}
+	return &t, true
+}
+
+// put stores t unless it contains source errors.
+func (c *Cache) put(key string, t *pkgTexts) error {
+	if c == nil || key == "" || t.hasErrors() {
+		return nil
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("encoding cache entry: %w", err)
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	// Write to a temporary file first to never leave partially written entries.
+	f, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating cache entry: %w", err)
+	}
+	_, err = f.Write(data)
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), c.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("writing cache entry: %w", err)
+	}
+	return nil
+}
+
+// pkgKeys computes and memoizes the cache keys of packages.
+type pkgKeys struct {
+	cache       *Cache
+	readerTypes []string
+	build       Build
+	byID        map[string]string
+}
+
+func newPkgKeys(cache *Cache, readerTypes []string, build Build) *pkgKeys {
+	return &pkgKeys{
+		cache: cache, readerTypes: readerTypes, build: build,
+		byID: make(map[string]string),
+	}
+}
+
+// of returns the cache key of pkg or "" if caching is disabled.
+func (k *pkgKeys) of(pkg *packages.Package) (string, error) {
+	if k.cache == nil {
+		return "", nil
+	}
+	if key, ok := k.byID[pkg.ID]; ok {
+		return key, nil
+	}
+
+	h := sha256.New()
+	write := func(s ...string) {
+		for _, s := range s {
+			_, _ = io.WriteString(h, s)
+			_, _ = io.WriteString(h, "\x00")
+		}
+	}
+	write(cacheFormat, k.cache.tokiVersion)
+	write(k.readerTypes...)
+	write(k.build.String())
+
+	switch m := pkg.Module; {
+	case m == nil:
+		// Standard library.
+		write("std", pkg.PkgPath)
+	case !m.Main && m.Version != "" && (m.Replace == nil || m.Replace.Version != ""):
+		// Immutable dependency from the module cache.
+		write("mod", m.Path, m.Version)
+		if m.Replace != nil {
+			write(m.Replace.Path, m.Replace.Version)
+		}
+		write(pkg.PkgPath)
+	default:
+		write("pkg", pkg.ID, pkg.PkgPath)
+		for _, f := range pkg.CompiledGoFiles {
+			write(f)
+			if err := writeFileHash(h, f); err != nil {
+				return "", err
+			}
+			if path, ok := templSourcePath(f); ok {
+				// Comments in .templ files don't end up in the generated code.
+				if err := writeFileHash(h, path); err != nil {
+					return "", err
+				}
+			}
+		}
+		imports := make([]string, 0, len(pkg.Imports))
+		for path := range pkg.Imports {
+			imports = append(imports, path)
+		}
+		slices.Sort(imports)
+		for _, path := range imports {
+			imp := pkg.Imports[path]
+			if slices.Contains(k.readerTypes, imp.PkgPath+".Reader") {
+				// The bundle changes with every generated message but
+				// its API the texts depend on only changes with the Toki version.
+				write("bundle", path)
+				continue
+			}
+			key, err := k.of(imp)
+			if err != nil {
+				return "", err
+			}
+			write(path, key)
+		}
+	}
+
+	key := hex.EncodeToString(h.Sum(nil))
+	k.byID[pkg.ID] = key
+	return key, nil
+}
+
+func writeFileHash(h hash.Hash, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil // Files generated by the build system may be missing.
+		}
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	fh := sha256.New()
+	if _, err := io.Copy(fh, f); err != nil {
+		return err
+	}
+	_, _ = h.Write(fh.Sum(nil))
+	return nil
+}
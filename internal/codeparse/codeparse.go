@@ -2,17 +2,20 @@ package codeparse
 
 import (
 	"bytes"
+	"cmp"
 	"errors"
 	"fmt"
 	"go/ast"
 	"go/constant"
 	"go/format"
+	"go/parser"
 	"go/token"
 	"go/types"
 	"iter"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync/atomic"
 
@@ -30,8 +33,23 @@ import (
 const (
 	FuncTypeString = "String"
 	FuncTypeWrite  = "Write"
+	FuncTypeAppend = "Append"
+	FuncTypeTIK    = "TIK" // A constant TIK value declared outside of any call.
 )
 
+// AttrFuzzy is the custom ARB message attribute marking translations that were
+// carried over from a similar, no longer existing TIK and need to be reviewed.
+// Its value is the previous TIK.
+const AttrFuzzy = "x-toki-fuzzy"
+
+// AttrPosition is the custom ARB message attribute holding the source position
+// (file:line) of the first call site of a message in the native catalog.
+const AttrPosition = "x-toki-position"
+
+// AttrLocations is the custom ARB message attribute listing the source positions
+// (file:line) of all call sites of a message.
+const AttrLocations = "x-toki-locations"
+
 var (
 	ErrUnsupportedSelectOption    = errors.New("unsupported select option")
 	ErrCantUnpackCompositeLiteral = errors.New("can't unpack composite literal")
@@ -41,7 +59,9 @@ var (
 type Statistics struct {
 	StringCalls    atomic.Int64
 	WriteCalls     atomic.Int64
+	AppendCalls    atomic.Int64
 	FilesTraversed atomic.Int64
+	PackagesCached atomic.Int64 // Packages reused from the scan cache.
 }
 
 type Parser struct {
@@ -51,16 +71,34 @@ type Parser struct {
 	icuDecoder    *icumsg.Tokenizer
 	icuTranslator *tik.ICUTranslator
 
-	genderType string
-	readerType string
+	cache *Cache
+
+	// readerTypes, genderTypes and tikTypes hold the fully qualified Reader,
+	// Gender and TIK types of each bundle
+	// (empty if the bundle package doesn't exist yet).
+	readerTypes []string
+	genderTypes []string
+	tikTypes    []string
+	genderType  string // Gender type of the bundle of the call being parsed.
+
+	// forwarders holds all valid functions annotated with DirectiveForward
+	// and forwardErrs the errors of all invalid ones.
+	forwarders  map[*types.Func]*forwarder
+	forwardErrs map[*ast.FuncDecl]error
+
+	// depDirs holds the GOROOT and GOMODCACHE directories.
+	depDirs []string
 }
 
+// NewParser creates a new parser. cache is optional and may be nil.
 func NewParser(
 	hasher *xxhash.Digest,
 	tikParser *tik.Parser,
 	translatorICU *tik.ICUTranslator,
+	cache *Cache,
 ) *Parser {
 	return &Parser{
+		cache:         cache,
 		hasher:        hasher,
 		tikParser:     tikParser,
 		arbDecoder:    arb.NewDecoder(),
@@ -72,11 +110,47 @@ func NewParser(
 type FnOnSrcErr func(pos token.Position, err error)
 
 type Text struct {
-	Position token.Position
-	TIK      tik.TIK
-	IDHash   string
-	Comments []string
-	Domain   *Domain // The domain this text belongs to (nil if none).
+	Position  token.Position   // Position of the first call site.
+	Positions []token.Position // Positions of all call sites including the first.
+	TIK       tik.TIK
+	IDHash    string
+	Comments  []string // Free-text description lines without directives.
+	Metadata  Metadata // Metadata declared by comment directives.
+	Domain    *Domain  // The domain this text belongs to (nil if none).
+	Package   string   // Import path of the package of the first call site.
+
+	// Builds holds the names of the build configurations the text was found in
+	// if more than one was scanned (see Scan.Builds).
+	Builds []string
+}
+
+// Locations returns the positions of all call sites of t formatted by
+// FmtPosition and ordered by file and line.
+func (t Text) Locations() []string {
+	positions := slices.Clone(t.Positions)
+	slices.SortFunc(positions, func(a, b token.Position) int {
+		return cmp.Or(
+			cmp.Compare(a.Filename, b.Filename),
+			cmp.Compare(a.Line, b.Line),
+		)
+	})
+	locations := make([]string, 0, len(positions))
+	for _, p := range positions {
+		if pos := FmtPosition(p); pos != "" {
+			locations = append(locations, pos)
+		}
+	}
+	return slices.Compact(locations) // Multiple calls on the same line.
+}
+
+// FmtPosition formats a source position as file:line
+// for the use in ARB message attributes.
+func FmtPosition(pos token.Position) string {
+	if pos.Filename == "" {
+		return ""
+	}
+	name := strings.TrimPrefix(filepath.ToSlash(pos.Filename), "/")
+	return fmt.Sprintf("%s:%d", name, pos.Line)
 }
 
 func (t Text) Context() string {
@@ -92,6 +166,16 @@ type SourceError struct {
 	Err error
 }
 
+// ARBError is an error in an .arb catalog file.
+// The file path isn't part of the error message.
+type ARBError struct {
+	File string // Absolute path.
+	Err  error
+}
+
+func (e *ARBError) Error() string { return e.Err.Error() }
+func (e *ARBError) Unwrap() error { return e.Err }
+
 type CatalogStatistics struct {
 	MessagesIncomplete atomic.Int64
 }
@@ -122,80 +206,315 @@ type Scan struct {
 	SourceErrors  *sync.Slice[SourceError]
 	Catalogs      *sync.Slice[*Catalog]
 	Domains       *DomainTree // Domain hierarchy discovered during scan.
+
+	// Builds holds the names of the scanned build configurations
+	// if there's more than one.
+	Builds []string
+
+	// BundleTIKs holds the TIKs by message ID declared in the existing bundle.
+	BundleTIKs map[string]string
+
+	// TrimPathBase is the absolute path the file paths of all positions
+	// are relative to, or empty if they're not trimmed.
+	TrimPathBase string
+}
+
+// TrimPath returns path relative to TrimPathBase if it's set.
+func (s *Scan) TrimPath(path string) string {
+	if s.TrimPathBase == "" {
+		return path
+	}
+	return strings.TrimPrefix(path, s.TrimPathBase)
 }
 
+// UntrimPath returns the absolute path of a path returned by TrimPath.
+func (s *Scan) UntrimPath(path string) string {
+	if s.TrimPathBase == "" {
+		return path
+	}
+	return filepath.Join(s.TrimPathBase, path)
+}
+
+// Parse scans all packages of the modules in modPaths and returns one scan
+// for each bundle package in bundlePkgPaths in the same order.
+// Every call site is attributed to the bundle whose Reader it calls.
+// The packages are scanned in every build configuration in builds,
+// or only in the build configuration of env if builds is empty.
+// Source file paths are made relative to trimPathBase unless it's empty.
 func (p *Parser) Parse(
-	env []string, pathPattern, bundlePkgPath string, trimpath bool,
-) (scan *Scan, err error) {
-	fset := token.NewFileSet()
-
-	conf := &packages.Config{
-		Mode: packages.NeedFiles |
-			packages.NeedSyntax |
-			packages.NeedTypes |
-			packages.NeedTypesInfo |
-			packages.NeedCompiledGoFiles |
-			packages.NeedDeps |
-			packages.NeedName |
-			packages.NeedModule,
-		Fset:  fset,
-		Tests: true,
-		Env:   env,
-	}
-	pkgs, err := packages.Load(conf, pathPattern+"/...")
-	for _, pkg := range pkgs {
-		if len(pkg.Errors) > 0 {
-			return nil, fmt.Errorf("errors in package %q: %v", pkg.Name, pkg.Errors)
+	env, modPaths, bundlePkgPaths []string, builds []Build, trimPathBase string,
+) (scans []*Scan, err error) {
+	patterns := make([]string, len(modPaths))
+	for i, modPath := range modPaths {
+		patterns[i] = modPath + "/..."
+	}
+	if p.depDirs, err = goEnv(env, "GOROOT", "GOMODCACHE"); err != nil {
+		return nil, err
+	}
+	bundleDirs := make([]string, len(bundlePkgPaths))
+	for i, path := range bundlePkgPaths {
+		if bundleDirs[i], err = filepath.Abs(path); err != nil {
+			return nil, fmt.Errorf("determining bundle package path: %w", err)
 		}
 	}
-	if err != nil {
-		return nil, fmt.Errorf("loading packages: %w", err)
+	if trimPathBase != "" {
+		if trimPathBase, err = filepath.Abs(trimPathBase); err != nil {
+			return nil, fmt.Errorf("determining trim path base: %w", err)
+		}
 	}
 
-	scan = &Scan{
-		Texts:         sync.NewSlice[Text](0),
-		TextIndexByID: sync.NewMap[string, int](0),
-		SourceErrors:  sync.NewSlice[SourceError](0),
-		Catalogs:      sync.NewSlice[*Catalog](1),
+	scans = make([]*Scan, len(bundleDirs))
+	for i := range bundleDirs {
+		scans[i] = &Scan{
+			Texts:         sync.NewSlice[Text](0),
+			TextIndexByID: sync.NewMap[string, int](0),
+			SourceErrors:  sync.NewSlice[SourceError](0),
+			Catalogs:      sync.NewSlice[*Catalog](1),
+			TrimPathBase:  trimPathBase,
+		}
 	}
 
-	bundlePkg := filepath.Base(bundlePkgPath)
-	pkgBundle := findBundlePkg(bundlePkg, pkgs)
-	if pkgBundle != nil {
-		log.Verbose("bundle detected", slog.String("directory", pkgBundle.Dir))
-		scan.TokiVersion = getConstantValue(pkgBundle, "TokiVersion")
-		defaultLocaleString := getConstantValue(pkgBundle, "DefaultLocale")
-		defaultLocale, err := language.Parse(defaultLocaleString)
+	if len(builds) == 0 {
+		builds = []Build{{}}
+	}
+	// Files are only traversed once, texts found in a file already traversed
+	// in another build configuration are merged.
+	seenFiles := make(map[string]struct{})
+	for i, build := range builds {
+		var name string // Only texts of multiple build configurations record them.
+		if len(builds) > 1 {
+			name = build.String()
+			for _, scan := range scans {
+				scan.Builds = append(scan.Builds, name)
+			}
+			log.Verbose("scanning build configuration", slog.String("build", name))
+		}
+		err := p.parseBuild(
+			build.env(env), build, name, patterns, bundleDirs, trimPathBase,
+			i == 0, seenFiles, scans,
+		)
 		if err != nil {
-			return nil, fmt.Errorf("invalid DefaultLocale value: %w", err)
+			return scans, err
 		}
-		scan.DefaultLocale = defaultLocale
+	}
+	return scans, nil
+}
 
-		err = p.CollectARBFiles(pkgBundle.Dir, scan)
+// parseBuild adds the texts of all packages matching patterns in build
+// configuration build to scans. The bundles and domains are read only if first.
+func (p *Parser) parseBuild(
+	env []string, build Build, name string, patterns, bundleDirs []string,
+	trimPathBase string, first bool, seenFiles map[string]struct{}, scans []*Scan,
+) error {
+	const modeList = packages.NeedName |
+		packages.NeedFiles |
+		packages.NeedCompiledGoFiles |
+		packages.NeedImports |
+		packages.NeedDeps |
+		packages.NeedModule
+	// Dependencies are type checked from source as well since export data
+	// isn't guaranteed to be readable across Go toolchain versions.
+	const modeTypes = modeList |
+		packages.NeedSyntax |
+		packages.NeedTypes |
+		packages.NeedTypesInfo
+
+	var listed, pkgs []*packages.Package
+	var fset *token.FileSet
+	if p.cache.isEmpty() {
+		// There's nothing to reuse, type check all packages right away.
+		fset = token.NewFileSet()
+		var err error
+		pkgs, err = packages.Load(&packages.Config{
+			Mode:       modeTypes,
+			BuildFlags: build.buildFlags(),
+			Fset:       fset,
+			ParseFile:  p.parseFile,
+			Tests:      true,
+			Env:        env,
+		}, patterns...)
+		if err := checkPkgErrors(pkgs); err != nil {
+			return err
+		}
 		if err != nil {
-			return scan, fmt.Errorf("searching .arb files: %w", err)
+			return fmt.Errorf("loading packages: %w", err)
+		}
+		listed = pkgs
+	} else {
+		// List all packages without type checking them first
+		// to find out which packages need to be type checked.
+		var err error
+		listed, err = packages.Load(&packages.Config{
+			Mode:       modeList,
+			BuildFlags: build.buildFlags(),
+			Tests:      true,
+			Env:        env,
+		}, patterns...)
+		if err := checkPkgErrors(listed); err != nil {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("loading packages: %w", err)
+		}
+	}
+
+	p.readerTypes = make([]string, len(bundleDirs))
+	p.genderTypes = make([]string, len(bundleDirs))
+	p.tikTypes = make([]string, len(bundleDirs))
+	var bundlePkgs []string
+	for i, dir := range bundleDirs {
+		if listedBundle := findBundlePkg(dir, listed); listedBundle != nil {
+			p.genderTypes[i] = listedBundle.PkgPath + ".Gender"
+			p.readerTypes[i] = listedBundle.PkgPath + ".Reader"
+			p.tikTypes[i] = listedBundle.PkgPath + ".TIK"
+			bundlePkgs = append(bundlePkgs, listedBundle.PkgPath)
 		}
-		p.genderType = pkgBundle.PkgPath + ".Gender"
-		p.readerType = pkgBundle.PkgPath + ".Reader"
 	}
 
-	// Discover TIK domains from .tokidomain files.
-	if modDir := moduleDir(pkgs); modDir != "" {
-		domains, err := DiscoverDomains(modDir)
+	// Reuse the texts of all packages that didn't change since the last scan.
+	keys := newPkgKeys(p.cache, p.readerTypes, build)
+	textsByID := make(map[string]*pkgTexts, len(listed))
+	staleKeys := make(map[string]string)
+	patterns = nil
+	for _, pkg := range listed {
+		if isPkgBundle(bundleDirs, pkg) {
+			continue
+		}
+		key, err := keys.of(pkg)
 		if err != nil {
-			return scan, fmt.Errorf("discovering domains: %w", err)
+			return fmt.Errorf("computing cache key for package %q: %w", pkg.ID, err)
+		}
+		if t, ok := p.cache.get(key); ok {
+			textsByID[pkg.ID] = t
+			for _, scan := range scans {
+				scan.PackagesCached.Add(1)
+			}
+			continue
+		}
+		staleKeys[pkg.ID] = key
+		if !slices.Contains(patterns, pkg.PkgPath) {
+			patterns = append(patterns, pkg.PkgPath)
 		}
-		scan.Domains = domains
+	}
+	if first {
+		patterns = append(patterns, bundlePkgs...)
 	}
 
-	p.collectTexts(fset, pkgs, bundlePkg, pathPattern, trimpath, scan)
+	if pkgs == nil && len(patterns) > 0 {
+		// Type check the bundles and all stale packages.
+		fset = token.NewFileSet()
+		var err error
+		pkgs, err = packages.Load(&packages.Config{
+			Mode:       modeTypes,
+			BuildFlags: build.buildFlags(),
+			Fset:       fset,
+			ParseFile:  p.parseFile,
+			Tests:      true,
+			Env:        env,
+			Dir:        moduleDir(listed),
+		}, patterns...)
+		if err := checkPkgErrors(pkgs); err != nil {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("loading packages: %w", err)
+		}
+	}
+
+	p.collectForwarders(pkgs)
+	for _, pkg := range pkgs {
+		if i := bundleIndex(bundleDirs, pkg.Dir); i != -1 {
+			if pkg.ForTest != "" || !first {
+				continue // Test variant of the bundle package or read already.
+			}
+			if err := p.readBundle(pkg, scans[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		key, ok := staleKeys[pkg.ID]
+		if !ok {
+			continue
+		}
+		t := p.collectPkgTexts(fset, pkg)
+		textsByID[pkg.ID] = t
+		if err := p.cache.put(key, t); err != nil {
+			log.Warn("writing scan cache", slog.String("err", err.Error()))
+		}
+	}
+
+	// Discover TIK domains from .tokidomain files of every module.
+	if modDirs := moduleDirs(listed); first && len(modDirs) > 0 {
+		domains, err := DiscoverDomains(modDirs...)
+		if err != nil {
+			return fmt.Errorf("discovering domains: %w", err)
+		}
+		for _, scan := range scans {
+			scan.Domains = domains
+		}
+	}
+
+	p.collectTexts(listed, textsByID, bundleDirs, trimPathBase, name, seenFiles, scans)
+	return nil
+}
+
+// parseFile parses the Go source file at filename. Function bodies of files
+// of the standard library and the module cache are removed since they can't
+// contain any texts and type checking without them is considerably faster.
+// Type errors this causes in dependencies, such as unused imports, are ignored.
+func (p *Parser) parseFile(
+	fset *token.FileSet, filename string, src []byte,
+) (*ast.File, error) {
+	if !slices.ContainsFunc(p.depDirs, func(dir string) bool {
+		return strings.HasPrefix(filename, dir+string(filepath.Separator))
+	}) {
+		return parser.ParseFile(fset, filename, src,
+			parser.AllErrors|parser.ParseComments)
+	}
+	f, err := parser.ParseFile(fset, filename, src,
+		parser.AllErrors|parser.SkipObjectResolution)
+	if f != nil {
+		for _, decl := range f.Decls {
+			if decl, ok := decl.(*ast.FuncDecl); ok {
+				decl.Body = nil
+			}
+		}
+	}
+	return f, err
+}
+
+// checkPkgErrors returns an error for the first package that has errors.
+func checkPkgErrors(pkgs []*packages.Package) error {
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return fmt.Errorf("errors in package %q: %v", pkg.Name, pkg.Errors)
+		}
+	}
+	return nil
+}
+
+// readBundle reads the settings, TIKs and catalogs of the existing bundle package.
+func (p *Parser) readBundle(pkgBundle *packages.Package, scan *Scan) error {
+	log.Verbose("bundle detected", slog.String("directory", pkgBundle.Dir))
+	scan.TokiVersion = getConstantValue(pkgBundle, "TokiVersion")
+	defaultLocaleString := getConstantValue(pkgBundle, "DefaultLocale")
+	defaultLocale, err := language.Parse(defaultLocaleString)
+	if err != nil {
+		return fmt.Errorf("invalid DefaultLocale value: %w", err)
+	}
+	scan.DefaultLocale = defaultLocale
+	scan.BundleTIKs = getBundleTIKs(pkgBundle)
 
-	return scan, nil
+	if err := p.CollectARBFiles(pkgBundle.Dir, scan); err != nil {
+		return fmt.Errorf("searching .arb files: %w", err)
+	}
+	return nil
 }
 
-func findBundlePkg(bundlePkg string, pkgs []*packages.Package) *packages.Package {
+// findBundlePkg returns the package in directory bundleDir.
+func findBundlePkg(bundleDir string, pkgs []*packages.Package) *packages.Package {
 	for _, pkg := range pkgs {
-		if isPkgBundle(bundlePkg, pkg) {
+		if pkg.ForTest == "" && sameDir(pkg.Dir, bundleDir) {
 			return pkg
 		}
 	}
@@ -223,6 +542,26 @@ func getConstantValue(p *packages.Package, name string) string {
 	return val.String()
 }
 
+// getBundleTIKs returns the values of all TIK constants declared in bundle package p.
+func getBundleTIKs(p *packages.Package) map[string]string {
+	if p == nil || p.Types == nil {
+		return nil
+	}
+	scope := p.Types.Scope()
+	m := make(map[string]string)
+	for _, name := range scope.Names() {
+		if !strings.HasPrefix(name, "msg") {
+			continue
+		}
+		c, ok := scope.Lookup(name).(*types.Const)
+		if !ok || c.Val() == nil || c.Val().Kind() != constant.String {
+			continue
+		}
+		m[name] = constant.StringVal(c.Val())
+	}
+	return m
+}
+
 var selectOptionsGender = []string{"male", "female"}
 
 func ICUSelectOptions(argName string) (
@@ -256,6 +595,11 @@ func (p *Parser) CollectARBFiles(bundlePkgDir string, scan *Scan) error {
 			slog.String("file", fileName))
 
 		path := filepath.Join(bundlePkgDir, fileName)
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("determining absolute file path: %w", err)
+		}
+
 		f, err := os.OpenFile(path, os.O_RDONLY, 0o644)
 		if err != nil {
 			return err
@@ -263,23 +607,20 @@ func (p *Parser) CollectARBFiles(bundlePkgDir string, scan *Scan) error {
 
 		arbFile, err := p.arbDecoder.Decode(f)
 		if err != nil {
-			return fmt.Errorf("parsing .arb file: %w", err)
+			return fmt.Errorf("parsing .arb file: %w",
+				&ARBError{File: absPath, Err: err})
 		}
 
 		if arbFile.Locale != locale {
-			return fmt.Errorf("locale in ARB file (%s) differs from file name (%s): %s",
-				arbFile.Locale.String(), locale.String(), fileName)
-		}
-
-		absPath, err := filepath.Abs(path)
-		if err != nil {
-			return fmt.Errorf("determining absolute file path: %w", err)
+			return &ARBError{File: absPath, Err: fmt.Errorf(
+				"locale in ARB file (%s) differs from file name (%s): %s",
+				arbFile.Locale.String(), locale.String(), fileName)}
 		}
 
 		catalog := &Catalog{ARB: arbFile, ARBFilePath: absPath}
 
 		for _, msg := range arbFile.Messages {
-			incomplete := IsMsgIncomplete(scan, arbFile, fileName, &msg)
+			incomplete := IsMsgIncomplete(scan, arbFile, absPath, &msg)
 			if incomplete {
 				catalog.MessagesIncomplete.Add(1)
 			}
@@ -294,7 +635,8 @@ func (p *Parser) CollectARBFiles(bundlePkgDir string, scan *Scan) error {
 func IsMsgIncomplete(
 	scan *Scan, arbFile *arb.File, fileName string, msg *arb.Message,
 ) bool {
-	incomplete := false
+	// Carried over translations are incomplete until reviewed.
+	incomplete := msg.CustomAttributes[AttrFuzzy] != nil
 	_, _ = icumsg.Analyze(
 		arbFile.Locale, msg.ICUMessage, msg.ICUMessageTokens,
 		ICUSelectOptions,
@@ -306,7 +648,7 @@ func IsMsgIncomplete(
 			scan.SourceErrors.Append(SourceError{
 				Err: fmt.Errorf("%w: %q", ErrUnsupportedSelectOption, name),
 				Position: token.Position{
-					Filename: fileName,
+					Filename: scan.TrimPath(fileName),
 				},
 			})
 			return nil
@@ -315,122 +657,372 @@ func IsMsgIncomplete(
 	return incomplete
 }
 
+// collectTexts adds the texts of all listed packages to scan in order.
+// Files shared between packages (such as between a package and its test variant)
+// are only traversed once. Files in seenFiles were traversed in another build
+// configuration already, their texts are merged with the existing ones.
+// build is the name of the build configuration or empty if there's only one.
 func (p *Parser) collectTexts(
-	fset *token.FileSet, pkgs []*packages.Package,
-	bundlePkg, pathPattern string, trimpath bool,
-	scan *Scan,
+	listed []*packages.Package, textsByID map[string]*pkgTexts,
+	bundleDirs []string, trimPathBase, build string,
+	seenFiles map[string]struct{}, scans []*Scan,
 ) {
-	seenFiles := make(map[string]struct{})
-	for _, pkg := range pkgs {
-		if isPkgBundle(bundlePkg, pkg) {
+	traversed := make(map[string]struct{})
+	for _, pkg := range listed {
+		if isPkgBundle(bundleDirs, pkg) {
 			continue
 		}
-		for iFile, file := range pkg.Syntax {
-			filePath := pkg.CompiledGoFiles[iFile]
-			if _, ok := seenFiles[filePath]; ok {
+		texts := textsByID[pkg.ID]
+		if texts == nil {
+			continue
+		}
+		for _, filePath := range pkg.CompiledGoFiles {
+			if _, ok := traversed[filePath]; ok {
 				continue
 			}
+			traversed[filePath] = struct{}{}
+			_, seen := seenFiles[filePath]
 			seenFiles[filePath] = struct{}{}
 
-			scan.FilesTraversed.Add(1)
+			if !seen {
+				for _, scan := range scans {
+					scan.FilesTraversed.Add(1)
+				}
+			}
 			var fileDomain *Domain
-			if scan.Domains != nil {
-				fileDomain = scan.Domains.ForDir(filepath.Dir(filePath))
+			if len(scans) > 0 && scans[0].Domains != nil {
+				fileDomain = scans[0].Domains.ForDir(filepath.Dir(filePath))
 			}
-			for _, decl := range file.Decls {
-				ast.Inspect(decl, func(node ast.Node) bool {
-					call, ok := node.(*ast.CallExpr)
-					if !ok {
-						return true
-					}
-
-					selector, ok := call.Fun.(*ast.SelectorExpr)
-					if !ok { // Not a function selector.
-						return true
-					}
+			for _, c := range texts.Files[filePath] {
+				i := slices.Index(p.readerTypes, c.Reader)
+				switch {
+				case c.Reader == "" && len(c.errs) > 0:
+					i = 0 // Errors not related to any bundle, such as invalid directives.
+				case i == -1:
+					continue // Reader of a bundle not being scanned.
+				}
+				p.addCall(c, fileDomain, pkg.PkgPath, trimPathBase, build, seen, scans[i])
+			}
+			log.Verbose("traversed file", slog.String("file", filePath))
+		}
+	}
+}
 
-					obj := pkg.TypesInfo.Uses[selector.Sel]
-					if obj == nil { // Not the right package and type.
-						return true
-					}
+// addCall adds the text of c to scan. build is the name of the build
+// configuration c was found in or empty if there's only one.
+// seen is true if the file of c was traversed in another build configuration
+// already, in which case c is only counted and reported once.
+func (p *Parser) addCall(
+	c call, fileDomain *Domain, pkgPath, trimPathBase, build string, seen bool,
+	scan *Scan,
+) {
+	if !seen {
+		switch c.Func {
+		case FuncTypeString:
+			scan.StringCalls.Add(1)
+		case FuncTypeWrite:
+			scan.WriteCalls.Add(1)
+		case FuncTypeAppend:
+			scan.AppendCalls.Add(1)
+		}
+	}
+	posCall := c.Pos
+	if trimPathBase != "" {
+		posCall.Filename = mustTrimPath(trimPathBase, posCall.Filename)
+	}
 
-					methodType, ok := obj.Type().(*types.Signature)
-					if !ok {
-						return true
-					}
+	if len(c.errs) > 0 {
+		for _, e := range c.errs {
+			if trimPathBase != "" {
+				e.Filename = mustTrimPath(trimPathBase, e.Filename)
+			}
+			if seen && hasSourceError(scan, e) {
+				continue
+			}
+			scan.SourceErrors.Append(e)
+		}
+		return
+	}
 
-					recv := methodType.Recv()
-					if recv == nil || recv.Type().String() != p.readerType {
-						return true // Not the right receiver type.
-					}
+	tikVal, err := p.tikParser.Parse(c.TIK)
+	if err != nil {
+		// Cached TIKs were valid when they were extracted.
+		scan.SourceErrors.Append(SourceError{
+			Position: posCall, Err: fmt.Errorf("TIK: %w", err),
+		})
+		return
+	}
 
-					funcType := selector.Sel.Name
-					posCall := fset.Position(call.Pos())
-					argumentOffset := 0
-					var tikVal tik.TIK
-					switch funcType {
-					case FuncTypeString:
-						scan.StringCalls.Add(1)
-					case FuncTypeWrite:
-						argumentOffset = 1
-						scan.WriteCalls.Add(1)
-					default:
-						return true // Not the right methods.
-					}
+	comments, meta, errs := parseDirectives(c.Comments, tikVal)
+	for _, err := range errs {
+		scan.SourceErrors.Append(SourceError{Position: posCall, Err: err})
+	}
 
-					tikVal, ok = p.parseTIK(fset, pkg, call, argumentOffset,
-						func(pos token.Position, err error) {
+	id := HashMessage(p.hasher, fileDomain, tikVal.Raw)
+	log.Verbose(c.Func, slog.String("pos", log.FmtPos(posCall)))
+	_ = scan.TextIndexByID.Access(func(s map[string]int) error {
+		if existingIdx, ok := s[id]; ok {
+			existing := scan.Texts.At(existingIdx)
+			if existing.TIK.Raw == tikVal.Raw {
+				_ = scan.Texts.Access(func(texts []Text) error {
+					t := &texts[existingIdx]
+					if !slices.Contains(t.Positions, posCall) {
+						t.Positions = append(t.Positions, posCall)
+						// Metadata is declared at any call site.
+						for _, err := range t.Metadata.merge(meta) {
 							scan.SourceErrors.Append(SourceError{
-								Position: pos, Err: fmt.Errorf("TIK: %w", err),
+								Position: posCall,
+								Err: fmt.Errorf("%w (at %s)",
+									err, FmtPosition(t.Position)),
 							})
-						})
-					if !ok {
-						return false
+						}
+					}
+					if build != "" && !slices.Contains(t.Builds, build) {
+						t.Builds = append(t.Builds, build)
 					}
+					return nil
+				})
+			} else {
+				scan.SourceErrors.Append(SourceError{
+					Position: posCall,
+					Err: fmt.Errorf(
+						"%w: %q collides with %q (at %s)",
+						ErrTIKCollision,
+						tikVal.Raw,
+						existing.TIK.Raw,
+						existing.Position,
+					),
+				})
+			}
+			return nil
+		}
+		var builds []string
+		if build != "" {
+			builds = []string{build}
+		}
+		index := scan.Texts.Append(Text{
+			Position:  posCall,
+			Positions: []token.Position{posCall},
+			IDHash:    id,
+			TIK:       tikVal,
+			Comments:  comments,
+			Metadata:  meta,
+			Domain:    fileDomain,
+			Package:   pkgPath,
+			Builds:    builds,
+		})
+		s[id] = index
+		return nil
+	})
+}
 
-					if trimpath {
-						posCall.Filename = mustTrimPath(pathPattern, posCall.Filename)
+// hasSourceError returns true if scan contains a source error equal to e.
+func hasSourceError(scan *Scan, e SourceError) bool {
+	for s := range scan.SourceErrors.SeqRead() {
+		if s.Position == e.Position && s.Err.Error() == e.Err.Error() {
+			return true
+		}
+	}
+	return false
+}
+
+// collectPkgTexts extracts all calls to the Reader methods and to forwarders
+// (see DirectiveForward) and all declared TIK values (see declaredTIK)
+// from the type checked pkg.
+func (p *Parser) collectPkgTexts(fset *token.FileSet, pkg *packages.Package) *pkgTexts {
+	t := &pkgTexts{Files: make(map[string][]call, len(pkg.Syntax))}
+	for iFile, file := range pkg.Syntax {
+		filePath := pkg.CompiledGoFiles[iFile]
+		position := fset.Position
+		leadingComments := func(call ast.Expr) []string {
+			return findLeadingComments(fset, file, call)
+		}
+		if m, ok := newTemplMap(filePath); ok {
+			// Refer to the .templ file instead of the generated Go file.
+			position = func(pos token.Pos) token.Position {
+				return m.position(fset.Position(pos))
+			}
+			leadingComments = func(call ast.Expr) []string {
+				if pos := position(call.Pos()); pos.Filename == m.path {
+					return m.leadingComments(pos.Line)
+				}
+				return findLeadingComments(fset, file, call)
+			}
+		}
+		var calls []call
+		for _, decl := range file.Decls {
+			// Calls inside a forwarder passing on its TIK parameter aren't usages.
+			var enclosing *forwarder
+			if decl, ok := decl.(*ast.FuncDecl); ok {
+				if err := p.forwardErrs[decl]; err != nil {
+					pos := forwardErrPos(fset, decl)
+					calls = append(calls, call{
+						Pos:  pos,
+						errs: []SourceError{{Position: pos, Err: err}},
+					})
+				}
+				if fn, ok := pkg.TypesInfo.Defs[decl.Name].(*types.Func); ok {
+					enclosing = p.forwarders[fn]
+				}
+			}
+			// skip holds the expressions of TIK type that aren't declarations
+			// of TIK values, such as the TIK arguments of calls and comparisons.
+			skip := make(map[ast.Expr]struct{})
+			ast.Inspect(decl, func(node ast.Node) bool {
+				switch n := node.(type) {
+				case *ast.CaseClause:
+					for _, e := range n.List {
+						skip[e] = struct{}{}
+					}
+				case ast.Expr:
+					if n, ok := n.(*ast.BinaryExpr); ok && isComparison(n.Op) {
+						skip[n.X], skip[n.Y] = struct{}{}, struct{}{}
 					}
+					if _, ok := skip[n]; ok {
+						return true
+					}
+					if c, ok := p.declaredTIK(position, pkg, n); ok {
+						c.Comments = leadingComments(n)
+						calls = append(calls, c)
+						return false
+					}
+				}
 
-					comments := findLeadingComments(fset, file, call)
-
-					id := HashMessage(p.hasher, fileDomain, tikVal.Raw)
-					log.Verbose(funcType,
-						slog.String("pos", log.FmtPos(posCall)))
-					_ = scan.TextIndexByID.Access(func(s map[string]int) error {
-						if existingIdx, ok := s[id]; ok {
-							existing := scan.Texts.At(existingIdx)
-							if existing.TIK.Raw != tikVal.Raw {
-								scan.SourceErrors.Append(SourceError{
-									Position: posCall,
-									Err: fmt.Errorf(
-										"%w: %q collides with %q (at %s)",
-										ErrTIKCollision,
-										tikVal.Raw,
-										existing.TIK.Raw,
-										existing.Position,
-									),
-								})
-							}
-							return nil
-						}
-						index := scan.Texts.Append(Text{
-							Position: posCall,
-							IDHash:   id,
-							TIK:      tikVal,
-							Comments: comments,
-							Domain:   fileDomain,
-						})
-						s[id] = index
-						return nil
+				callExpr, ok := node.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				if bundle, ok := p.uncheckedTIK(pkg, callExpr); ok {
+					pos := position(callExpr.Pos())
+					calls = append(calls, call{
+						Reader: p.readerTypes[bundle],
+						Pos:    pos,
+						errs: []SourceError{{
+							Position: pos, Err: errors.New("TIK: not a constant"),
+						}},
 					})
+					return false
+				}
 
+				target, ok := p.callTarget(pkg, callExpr)
+				if !ok || target.TIK >= len(callExpr.Args) {
+					return true // Neither a Reader method nor a forwarder.
+				}
+				skip[callExpr.Args[target.TIK]] = struct{}{}
+				if enclosing != nil && enclosing.isTIKParam(callExpr, target.TIK) {
 					return true
-				})
-			}
-			log.Verbose("traversed file", slog.String("file", filePath))
+				}
+				if p.isDynamicTIK(pkg, callExpr.Args[target.TIK]) {
+					return true // Its values are checked where they're declared.
+				}
+				p.genderType = p.genderTypes[slices.Index(p.readerTypes, target.Reader)]
+
+				c := call{
+					Reader: target.Reader,
+					Func:   target.Func,
+					Pos:    position(callExpr.Pos()),
+				}
+				tikVal, ok := p.parseTIK(position, pkg, callExpr, target.TIK, target.Args,
+					func(pos token.Position, err error) {
+						c.errs = append(c.errs, SourceError{
+							Position: pos, Err: fmt.Errorf("TIK: %w", err),
+						})
+					})
+				calls = append(calls, c)
+				if !ok {
+					return false
+				}
+				calls[len(calls)-1].TIK = tikVal.Raw
+				calls[len(calls)-1].Comments = leadingComments(callExpr)
+				return true
+			})
 		}
+		t.Files[filePath] = calls
+	}
+	return t
+}
+
+// declaredTIK returns the TIK value declared by the constant expression expr
+// of a bundle TIK type, such as in:
+//
+//	const greeting tokibundle.TIK = "Hello {text}!"
+//	var labels = map[Status]tokibundle.TIK{StatusActive: "Active"}
+//
+// Returns false if expr isn't of a TIK type, isn't constant or merely refers
+// to a named constant declared elsewhere.
+func (p *Parser) declaredTIK(
+	position func(token.Pos) token.Position, pkg *packages.Package, expr ast.Expr,
+) (c call, ok bool) {
+	switch expr.(type) {
+	case *ast.Ident, *ast.SelectorExpr:
+		return c, false // References to named constants.
+	}
+	tv, ok := pkg.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return c, false
+	}
+	bundle := p.tikTypeIndex(tv.Type)
+	if bundle == -1 {
+		return c, false
+	}
+	c = call{Reader: p.readerTypes[bundle], Func: FuncTypeTIK, Pos: position(expr.Pos())}
+	tk, err := p.tikParser.Parse(constant.StringVal(tv.Value))
+	if err != nil {
+		c.errs = []SourceError{{Position: c.Pos, Err: fmt.Errorf("TIK: %w", err)}}
+		return c, true
+	}
+	c.TIK = tk.Raw
+	return c, true
+}
+
+// isDynamicTIK returns true if expr is a non-constant value of a bundle TIK type
+// that doesn't originate from converting a non-constant string.
+// Its arguments can't be checked because its value is only known at runtime,
+// but every value it may hold is a declared TIK value (see declaredTIK).
+func (p *Parser) isDynamicTIK(pkg *packages.Package, expr ast.Expr) bool {
+	tv, ok := pkg.TypesInfo.Types[expr]
+	if !ok || tv.Value != nil || p.tikTypeIndex(tv.Type) == -1 {
+		return false
+	}
+	_, unchecked := p.uncheckedTIK(pkg, expr)
+	return !unchecked
+}
+
+// uncheckedTIK returns the index of the bundle if expr converts
+// a non-constant value that isn't a TIK to the TIK type of the bundle,
+// which would bypass all checks.
+func (p *Parser) uncheckedTIK(pkg *packages.Package, expr ast.Expr) (bundle int, ok bool) {
+	conv, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok || len(conv.Args) != 1 || !pkg.TypesInfo.Types[conv.Fun].IsType() {
+		return -1, false
+	}
+	bundle = p.tikTypeIndex(pkg.TypesInfo.Types[conv.Fun].Type)
+	if bundle == -1 {
+		return -1, false
 	}
+	operand := pkg.TypesInfo.Types[conv.Args[0]]
+	if operand.Value != nil || p.tikTypeIndex(operand.Type) != -1 {
+		return -1, false
+	}
+	return bundle, true
+}
+
+// tikTypeIndex returns the index of the bundle t is the TIK type of
+// or -1 if t isn't a TIK type.
+func (p *Parser) tikTypeIndex(t types.Type) int {
+	if t == nil {
+		return -1
+	}
+	return slices.Index(p.tikTypes, t.String())
+}
+
+// isComparison returns true if op is a comparison operator.
+func isComparison(op token.Token) bool {
+	switch op {
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		return true
+	}
+	return false
 }
 
 func mustFmtExpr(e ast.Expr) string {
@@ -443,11 +1035,11 @@ func mustFmtExpr(e ast.Expr) string {
 }
 
 func (p *Parser) parseTIK(
-	fileset *token.FileSet, pkg *packages.Package, call *ast.CallExpr,
-	methodArgumentOffset int, onSrcErr FnOnSrcErr,
+	position func(token.Pos) token.Position, pkg *packages.Package, call *ast.CallExpr,
+	tikIndex, argsIndex int, onSrcErr FnOnSrcErr,
 ) (tk tik.TIK, ok bool) {
-	arg := call.Args[methodArgumentOffset]
-	pos := fileset.Position(arg.Pos())
+	arg := call.Args[tikIndex]
+	pos := position(arg.Pos())
 	tv, ok := pkg.TypesInfo.Types[arg]
 	if !ok {
 		onSrcErr(pos, errors.New("no type info"))
@@ -459,7 +1051,7 @@ func (p *Parser) parseTIK(
 		return tk, false
 	}
 
-	if tv.Type.String() != "string" {
+	if tv.Type.String() != "string" && p.tikTypeIndex(tv.Type) == -1 {
 		onSrcErr(pos, errors.New("not a string constant"))
 		return tk, false
 	}
@@ -486,7 +1078,7 @@ func (p *Parser) parseTIK(
 
 	ok = true
 	index := 0
-	seq, err := iterArgs(call, methodArgumentOffset)
+	seq, err := iterArgs(call, argsIndex)
 	if err != nil {
 		onSrcErr(pos, err)
 		return tk, false
@@ -584,14 +1176,15 @@ func (p *Parser) parseTIK(
 	return tk, ok
 }
 
-func iterArgs(call *ast.CallExpr, argOffset int) (iter.Seq[ast.Expr], error) {
-	if len(call.Args)+argOffset < 2 {
+// iterArgs iterates over the TIK arguments of call starting at argsIndex.
+// argsIndex is -1 if the called function accepts no TIK arguments.
+func iterArgs(call *ast.CallExpr, argsIndex int) (iter.Seq[ast.Expr], error) {
+	if argsIndex < 0 || argsIndex >= len(call.Args) {
 		return func(yield func(ast.Expr) bool) {}, nil
 	}
 	isEllipsis := call.Ellipsis.IsValid() // true if passed as slice...
-	if isEllipsis && argOffset+1 < len(call.Args) {
-		secondArg := call.Args[argOffset+1]
-		compositeLit, ok := secondArg.(*ast.CompositeLit)
+	if isEllipsis {
+		compositeLit, ok := call.Args[argsIndex].(*ast.CompositeLit)
 		if !ok {
 			return nil, ErrCantUnpackCompositeLiteral
 		}
@@ -606,7 +1199,7 @@ func iterArgs(call *ast.CallExpr, argOffset int) (iter.Seq[ast.Expr], error) {
 	}
 	return func(yield func(ast.Expr) bool) {
 		// Iterate over variadic arguments like: foo.String("msg", a, b, c)
-		for _, a := range call.Args[argOffset+1:] {
+		for _, a := range call.Args[argsIndex:] {
 			if !yield(a) {
 				break
 			}
@@ -836,21 +1429,46 @@ func moduleDir(pkgs []*packages.Package) string {
 	return ""
 }
 
-func isPkgBundle(bundlePkg string, pkg *packages.Package) bool {
-	if pkg.Module == nil {
-		return false
-	}
-	if c, ok := strings.CutPrefix(pkg.Dir, pkg.Module.Dir); ok {
-		if len(c) > 1 && c[0] == '/' && strings.HasSuffix(c[1:], bundlePkg) {
-			return true
+// moduleDirs returns the sorted root directories of all main modules of pkgs.
+// There's more than one main module in go.work workspaces.
+func moduleDirs(pkgs []*packages.Package) []string {
+	var dirs []string
+	for _, pkg := range pkgs {
+		if pkg.Module != nil && pkg.Module.Main && pkg.Module.Dir != "" &&
+			!slices.Contains(dirs, pkg.Module.Dir) {
+			dirs = append(dirs, pkg.Module.Dir)
 		}
 	}
-	return false
+	slices.Sort(dirs)
+	return dirs
+}
+
+// isPkgBundle reports whether pkg is any of the bundle packages in bundleDirs.
+func isPkgBundle(bundleDirs []string, pkg *packages.Package) bool {
+	return bundleIndex(bundleDirs, pkg.Dir) != -1
 }
 
-func mustTrimPath(basePattern, s string) string {
-	basePattern = strings.TrimSuffix(basePattern, "/...")
-	abs, err := filepath.Abs(basePattern)
+// bundleIndex returns the index of dir in bundleDirs or -1 if it isn't a bundle.
+func bundleIndex(bundleDirs []string, dir string) int {
+	return slices.IndexFunc(bundleDirs, func(d string) bool { return sameDir(d, dir) })
+}
+
+// sameDir reports whether the absolute paths a and b refer to the same directory
+// even if either is a symbolic link.
+func sameDir(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if filepath.Base(a) != filepath.Base(b) {
+		return false
+	}
+	ra, errA := filepath.EvalSymlinks(a)
+	rb, errB := filepath.EvalSymlinks(b)
+	return errA == nil && errB == nil && ra == rb
+}
+
+func mustTrimPath(base, s string) string {
+	abs, err := filepath.Abs(base)
 	if err != nil {
 		panic(fmt.Errorf("getting absolute path: %w", err))
 	}
@@ -871,6 +1489,10 @@ func findLeadingComments(fset *token.FileSet, file *ast.File, call ast.Expr) []s
 		}
 		if isImmediatelyAbove(cg.End()) {
 			for _, c := range cg.List {
+				if strings.HasPrefix(c.Text, directivePrefix) {
+					lines = append(lines, c.Text) // Directives are parsed later.
+					continue
+				}
 				s := strings.TrimPrefix(c.Text, "//")
 				s = strings.TrimSpace(s)
 				lines = append(lines, s)
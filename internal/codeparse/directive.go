@@ -0,0 +1,182 @@
+package codeparse
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	tik "github.com/romshark/tik/tik-go"
+)
+
+// Comment directives providing structured translator metadata.
+// They're written on their own line in the comment immediately above a call
+// and are excluded from the free-text description:
+//
+//	// Shown on the checkout button.
+//	//toki:context checkout page
+//	//toki:maxlen 24
+//	//toki:maxwidth 24
+//	//toki:example var0=Alice
+//	//toki:screenshot docs/checkout.png
+//	r.String("Pay {text}", name)
+const (
+	DirectiveContext    = "//toki:context"
+	DirectiveMaxLen     = "//toki:maxlen"
+	DirectiveMaxWidth   = "//toki:maxwidth"
+	DirectiveExample    = "//toki:example"
+	DirectiveScreenshot = "//toki:screenshot"
+)
+
+// AttrMaxLen is the custom ARB message attribute holding the maximum length
+// of the message declared by DirectiveMaxLen.
+const AttrMaxLen = "x-toki-maxlen"
+
+// AttrMaxWidth is the custom ARB message attribute holding the maximum
+// display width of the message declared by DirectiveMaxWidth.
+const AttrMaxWidth = "x-toki-maxwidth"
+
+// AttrScreenshots is the custom ARB message attribute holding the paths
+// of the screenshots declared by DirectiveScreenshot.
+const AttrScreenshots = "x-toki-screenshots"
+
+// directivePrefix is the prefix of all comment directives.
+// Like Go directives they mustn't have a space after the slashes.
+const directivePrefix = "//toki:"
+
+var (
+	ErrInvalidDirective  = errors.New("invalid directive")
+	ErrDirectiveConflict = errors.New("conflicting directive")
+)
+
+// Metadata is the structured translator metadata of a text
+// declared by comment directives.
+type Metadata struct {
+	Context     string            // Additional context for translators.
+	MaxLen      int               // Maximum number of characters or 0 if unlimited.
+	MaxWidth    int               // Maximum display width or 0 if unlimited.
+	Examples    map[string]string // Example values by placeholder name (var0, var1, ...).
+	Screenshots []string          // Paths to screenshots showing the text.
+}
+
+// parseDirectives separates the comment directives from the free-text
+// description lines of the comments above a call to tk.
+func parseDirectives(
+	comments []string, tk tik.TIK,
+) (description []string, meta Metadata, errs []error) {
+	placeholders := 0
+	for range tk.Placeholders() {
+		placeholders++
+	}
+	for _, line := range comments {
+		name, value, ok := cutDirective(line)
+		if !ok {
+			description = append(description, line)
+			continue
+		}
+		switch name {
+		case DirectiveContext:
+			if value == "" {
+				errs = append(errs, fmt.Errorf("%w: %s: missing context", ErrInvalidDirective, name))
+				continue
+			}
+			meta.Context = strings.TrimSpace(meta.Context + " " + value)
+		case DirectiveMaxLen, DirectiveMaxWidth:
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				errs = append(errs, fmt.Errorf("%w: %s: %q must be a positive integer",
+					ErrInvalidDirective, name, value))
+				continue
+			}
+			if name == DirectiveMaxLen {
+				meta.MaxLen = n
+			} else {
+				meta.MaxWidth = n
+			}
+		case DirectiveExample:
+			placeholder, example, ok := strings.Cut(value, "=")
+			if !ok || example == "" {
+				errs = append(errs, fmt.Errorf("%w: %s: expected <placeholder>=<value>",
+					ErrInvalidDirective, name))
+				continue
+			}
+			i, err := strconv.Atoi(strings.TrimPrefix(placeholder, "var"))
+			if !strings.HasPrefix(placeholder, "var") || err != nil ||
+				i < 0 || i >= placeholders {
+				errs = append(errs, fmt.Errorf("%w: %s: unknown placeholder %q",
+					ErrInvalidDirective, name, placeholder))
+				continue
+			}
+			if meta.Examples == nil {
+				meta.Examples = make(map[string]string)
+			}
+			meta.Examples[placeholder] = example
+		case DirectiveScreenshot:
+			if value == "" {
+				errs = append(errs, fmt.Errorf("%w: %s: missing path", ErrInvalidDirective, name))
+				continue
+			}
+			meta.Screenshots = append(meta.Screenshots, value)
+		default:
+			errs = append(errs, fmt.Errorf("%w: unknown directive %q",
+				ErrInvalidDirective, name))
+		}
+	}
+	return description, meta, errs
+}
+
+// merge merges the metadata of another call site of the same text into m
+// keeping the strictest maximum length and width and all screenshots.
+// Returns an error for every context and example that differs from m.
+func (m *Metadata) merge(other Metadata) (errs []error) {
+	if other.Context != "" {
+		switch m.Context {
+		case "":
+			m.Context = other.Context
+		case other.Context:
+		default:
+			errs = append(errs, fmt.Errorf("%w: %s: %q differs from %q",
+				ErrDirectiveConflict, DirectiveContext, other.Context, m.Context))
+		}
+	}
+	strictest := func(a, b int) int {
+		if a == 0 || (b != 0 && b < a) {
+			return b
+		}
+		return a
+	}
+	m.MaxLen = strictest(m.MaxLen, other.MaxLen)
+	m.MaxWidth = strictest(m.MaxWidth, other.MaxWidth)
+	for _, name := range slices.Sorted(maps.Keys(other.Examples)) {
+		example := other.Examples[name]
+		existing, ok := m.Examples[name]
+		switch {
+		case !ok:
+			if m.Examples == nil {
+				m.Examples = make(map[string]string)
+			}
+			m.Examples[name] = example
+		case existing != example:
+			errs = append(errs, fmt.Errorf("%w: %s: %s=%q differs from %q",
+				ErrDirectiveConflict, DirectiveExample, name, example, existing))
+		}
+	}
+	for _, path := range other.Screenshots {
+		if !slices.Contains(m.Screenshots, path) {
+			m.Screenshots = append(m.Screenshots, path)
+		}
+	}
+	return errs
+}
+
+// cutDirective returns the name and the value of a comment line
+// starting with "//toki:". Returns false if line isn't a directive.
+func cutDirective(line string) (name, value string, ok bool) {
+	if !strings.HasPrefix(line, directivePrefix) {
+		return "", "", false
+	}
+	name, value, _ = strings.Cut(line, " ")
+	return name, strings.TrimSpace(value), true
+}
@@ -88,11 +88,23 @@ func parseDomainFile(path string) (*domainFile, error) {
 	return &df, nil
 }
 
-// DiscoverDomains walks root and builds a DomainTree from all .tokidomain files found.
-func DiscoverDomains(root string) (*DomainTree, error) {
+// DiscoverDomains walks the module roots and builds a DomainTree from all
+// .tokidomain files found. Each module has its own independent domain hierarchy,
+// nested modules are skipped.
+func DiscoverDomains(roots ...string) (*DomainTree, error) {
+	byDir := make(map[string]*Domain)
+	for _, root := range roots {
+		if err := discoverModuleDomains(root, byDir); err != nil {
+			return nil, err
+		}
+	}
+	return &DomainTree{byDir: byDir}, nil
+}
+
+func discoverModuleDomains(root string, byDir map[string]*Domain) error {
 	root, err := filepath.Abs(root)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// First pass: collect all domain files.
@@ -107,6 +119,9 @@ func DiscoverDomains(root string) (*DomainTree, error) {
 			return nil // Skip inaccessible directories.
 		}
 		if d.IsDir() {
+			if path != root && isModuleDir(path) {
+				return filepath.SkipDir // Nested modules have their own domains.
+			}
 			return nil
 		}
 		if d.Name() != DomainFileName {
@@ -121,24 +136,26 @@ func DiscoverDomains(root string) (*DomainTree, error) {
 		return nil
 	})
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// Build the tree: create Domain objects and resolve parents.
-	byDir := make(map[string]*Domain, len(entries))
+	module := make(map[string]*Domain, len(entries))
 	for _, e := range entries {
-		byDir[e.dir] = &Domain{
+		dom := &Domain{
 			Name:        e.df.Name,
 			Description: e.df.Description,
 			Dir:         e.dir,
 		}
+		module[e.dir] = dom
+		byDir[e.dir] = dom
 	}
 
 	// Second pass: resolve parent/child pointers by walking up the directory tree.
-	for dir, dom := range byDir {
+	for dir, dom := range module {
 		d := filepath.Dir(dir)
 		for d != dir {
-			if parent, ok := byDir[d]; ok {
+			if parent, ok := module[d]; ok {
 				dom.Parent = parent
 				parent.SubDomains = append(parent.SubDomains, dom)
 				break
@@ -151,5 +168,10 @@ func DiscoverDomains(root string) (*DomainTree, error) {
 		}
 	}
 
-	return &DomainTree{byDir: byDir}, nil
+	return nil
+}
+
+func isModuleDir(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "go.mod"))
+	return err == nil
 }
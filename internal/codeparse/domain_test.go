@@ -79,6 +79,25 @@ func TestDiscoverDomains(t *testing.T) {
 	require.Equal(t, domainAPI, domainBilling)
 }
 
+func TestDiscoverDomainsMultipleModules(t *testing.T) {
+	root := t.TempDir()
+	modA, modB := filepath.Join(root, "moda"), filepath.Join(root, "moda", "modb")
+	writeDomainFile(t, modA, "name: a\n")
+	writeDomainFile(t, modB, "name: b\n")
+	require.NoError(t, os.WriteFile(
+		filepath.Join(modB, "go.mod"), []byte("module modb\n"), 0o644,
+	))
+
+	dt, err := codeparse.DiscoverDomains(modA, modB)
+	require.NoError(t, err)
+
+	// The nested module b has its own domain hierarchy.
+	domainB := dt.ForDir(modB)
+	require.NotNil(t, domainB)
+	require.Nil(t, domainB.Parent)
+	require.Empty(t, subdomainNames(dt.ForDir(modA)))
+}
+
 func TestDiscoverDomainsNoDomainFiles(t *testing.T) {
 	root := t.TempDir()
 	dt, err := codeparse.DiscoverDomains(root)
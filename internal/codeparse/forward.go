@@ -0,0 +1,246 @@
+package codeparse
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// DirectiveForward annotates a function that forwards a TIK and its arguments
+// to a Reader method. Its call sites are treated like calls to the Reader method:
+//
+//	//toki:forward tik=1 args=2
+//	func (h *Handler) t(r *http.Request, tik tokibundle.TIK, args ...any) string
+//
+// tik is the index of the TIK parameter, which is either of the bundle TIK type
+// or a string converted to it when forwarded, and args the index of
+// the variadic arguments parameter (optional). Indexes don't count the receiver.
+const DirectiveForward = "//toki:forward"
+
+var ErrInvalidForwardDirective = errors.New("invalid " + DirectiveForward + " directive")
+
+// forwarder is a function annotated with DirectiveForward.
+type forwarder struct {
+	TIK  int // Index of the TIK parameter.
+	Args int // Index of the variadic arguments parameter or -1 if none.
+
+	// Reader and Func are the Reader type and method the TIK is forwarded to.
+	// Both are empty until the forwarder is resolved.
+	Reader string
+	Func   string
+
+	tikParam *types.Var
+	decl     *ast.FuncDecl
+	pkg      *packages.Package
+}
+
+// collectForwarders finds all functions annotated with DirectiveForward
+// in the module packages of pkgs and their dependencies.
+func (p *Parser) collectForwarders(pkgs []*packages.Package) {
+	p.forwarders = make(map[*types.Func]*forwarder)
+	p.forwardErrs = make(map[*ast.FuncDecl]error)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if pkg.Module == nil || !pkg.Module.Main || pkg.TypesInfo == nil {
+			return
+		}
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				decl, ok := decl.(*ast.FuncDecl)
+				if !ok {
+					continue
+				}
+				directive := findForwardDirective(decl.Doc)
+				if directive == "" {
+					continue
+				}
+				fn, ok := pkg.TypesInfo.Defs[decl.Name].(*types.Func)
+				if !ok {
+					continue
+				}
+				fw, err := p.parseForwardDirective(directive, fn.Signature())
+				if err != nil {
+					p.forwardErrs[decl] = err
+					continue
+				}
+				fw.decl, fw.pkg = decl, pkg
+				p.forwarders[fn] = fw
+			}
+		}
+	})
+
+	// Resolve forwarders calling other forwarders until no more can be resolved.
+	for resolved := true; resolved; {
+		resolved = false
+		for _, fw := range p.forwarders {
+			if fw.Reader == "" && p.resolveForwarder(fw) {
+				resolved = true
+			}
+		}
+	}
+	for fn, fw := range p.forwarders {
+		if fw.Reader == "" {
+			p.forwardErrs[fw.decl] = fmt.Errorf(
+				"%w: parameter %q isn't passed as TIK to any Reader method",
+				ErrInvalidForwardDirective, fw.tikParam.Name())
+			delete(p.forwarders, fn)
+		}
+	}
+}
+
+// resolveForwarder finds the Reader method fw forwards its TIK to
+// either directly or through another resolved forwarder.
+// Returns false if there is none.
+func (p *Parser) resolveForwarder(fw *forwarder) (ok bool) {
+	ast.Inspect(fw.decl.Body, func(node ast.Node) bool {
+		if ok {
+			return false
+		}
+		callExpr, isCall := node.(*ast.CallExpr)
+		if !isCall {
+			return true
+		}
+		target, isTarget := p.callTarget(fw.pkg, callExpr)
+		if !isTarget || target.Reader == "" || !fw.isTIKParam(callExpr, target.TIK) {
+			return true
+		}
+		fw.Reader, fw.Func = target.Reader, target.Func
+		ok = true
+		return false
+	})
+	return ok
+}
+
+// isTIKParam returns true if the argument at index i of call
+// is the TIK parameter of fw, optionally converted to another type.
+func (fw *forwarder) isTIKParam(call *ast.CallExpr, i int) bool {
+	if i >= len(call.Args) {
+		return false
+	}
+	arg := ast.Unparen(call.Args[i])
+	if conv, ok := arg.(*ast.CallExpr); ok && len(conv.Args) == 1 &&
+		fw.pkg.TypesInfo.Types[conv.Fun].IsType() {
+		arg = ast.Unparen(conv.Args[0])
+	}
+	ident, ok := arg.(*ast.Ident)
+	return ok && fw.pkg.TypesInfo.Uses[ident] == fw.tikParam
+}
+
+// callTarget returns the Reader method or forwarder called by call
+// as a forwarder with the indexes of the TIK and its arguments.
+// Returns false if call calls neither.
+func (p *Parser) callTarget(
+	pkg *packages.Package, call *ast.CallExpr,
+) (target *forwarder, ok bool) {
+	var ident *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	}
+	if ident == nil {
+		return nil, false
+	}
+	fn, ok := pkg.TypesInfo.Uses[ident].(*types.Func)
+	if !ok {
+		return nil, false
+	}
+	fn = fn.Origin()
+
+	if recv := fn.Signature().Recv(); recv != nil {
+		if bundle := slices.Index(p.readerTypes, recv.Type().String()); bundle != -1 {
+			switch fn.Name() {
+			case FuncTypeString:
+				return &forwarder{
+					TIK: 0, Args: 1, Reader: p.readerTypes[bundle], Func: FuncTypeString,
+				}, true
+			case FuncTypeWrite:
+				return &forwarder{
+					TIK: 1, Args: 2, Reader: p.readerTypes[bundle], Func: FuncTypeWrite,
+				}, true
+			case FuncTypeAppend:
+				return &forwarder{
+					TIK: 1, Args: 2, Reader: p.readerTypes[bundle], Func: FuncTypeAppend,
+				}, true
+			}
+			return nil, false // Not the right methods.
+		}
+	}
+	fw, ok := p.forwarders[fn]
+	return fw, ok
+}
+
+// findForwardDirective returns the DirectiveForward comment line in doc
+// or "" if there is none.
+func findForwardDirective(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	for _, c := range doc.List {
+		if c.Text == DirectiveForward ||
+			strings.HasPrefix(c.Text, DirectiveForward+" ") {
+			return c.Text
+		}
+	}
+	return ""
+}
+
+// parseForwardDirective parses directive and checks it against
+// the signature of the annotated function.
+func (p *Parser) parseForwardDirective(
+	directive string, sig *types.Signature,
+) (*forwarder, error) {
+	fw := &forwarder{TIK: -1, Args: -1}
+	for field := range strings.FieldsSeq(strings.TrimPrefix(directive, DirectiveForward)) {
+		key, value, _ := strings.Cut(field, "=")
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("%w: %q must be a non-negative parameter index",
+				ErrInvalidForwardDirective, field)
+		}
+		switch key {
+		case "tik":
+			fw.TIK = i
+		case "args":
+			fw.Args = i
+		default:
+			return nil, fmt.Errorf("%w: unknown option %q",
+				ErrInvalidForwardDirective, key)
+		}
+	}
+
+	params := sig.Params()
+	switch {
+	case fw.TIK == -1:
+		return nil, fmt.Errorf("%w: missing option tik", ErrInvalidForwardDirective)
+	case fw.TIK >= params.Len():
+		return nil, fmt.Errorf("%w: tik=%d is out of range",
+			ErrInvalidForwardDirective, fw.TIK)
+	case !types.Identical(params.At(fw.TIK).Type(), types.Typ[types.String]) &&
+		p.tikTypeIndex(params.At(fw.TIK).Type()) == -1:
+		return nil, fmt.Errorf("%w: parameter %d must be a string or TIK but is %s",
+			ErrInvalidForwardDirective, fw.TIK, params.At(fw.TIK).Type())
+	case fw.Args != -1 && (!sig.Variadic() || fw.Args != params.Len()-1):
+		return nil, fmt.Errorf("%w: args=%d must be the variadic parameter",
+			ErrInvalidForwardDirective, fw.Args)
+	}
+	fw.tikParam = params.At(fw.TIK)
+	return fw, nil
+}
+
+// forwardErrPos returns the position of the DirectiveForward comment of decl.
+func forwardErrPos(fset *token.FileSet, decl *ast.FuncDecl) token.Position {
+	for _, c := range decl.Doc.List {
+		if strings.HasPrefix(c.Text, DirectiveForward) {
+			return fset.Position(c.Pos())
+		}
+	}
+	return fset.Position(decl.Pos())
+}
@@ -0,0 +1,157 @@
+package codeparse
+
+import (
+	"bytes"
+	"go/scanner"
+	"go/token"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/a-h/templ/generator"
+	templparser "github.com/a-h/templ/parser/v2"
+
+	"github.com/romshark/toki/internal/log"
+)
+
+// templGoFileSuffix is the suffix of Go files generated by templ
+// (https://templ.guide) from .templ files of the same name.
+const templGoFileSuffix = "_templ.go"
+
+// templSourcePath returns the path of the .templ file goFilePath
+// was generated from. Returns false if goFilePath isn't generated by templ.
+func templSourcePath(goFilePath string) (string, bool) {
+	base, ok := strings.CutSuffix(goFilePath, templGoFileSuffix)
+	if !ok {
+		return "", false
+	}
+	return base + ".templ", true
+}
+
+// templMap maps positions in a Go file generated by templ back
+// to the .templ file it was generated from.
+//
+// The source map templ produces refers to the unformatted Go code,
+// so the .templ file is generated again and the tokens of the unformatted
+// code are matched with the tokens of the formatted code on disk.
+type templMap struct {
+	path       string                 // Path of the .templ file.
+	lines      []string               // Lines of the .templ file.
+	offsets    []int                  // Offsets of the tokens of the Go file.
+	genOffsets []int                  // Offsets of the same tokens in the generated code.
+	genFile    *token.File            // The generated code.
+	sourceMap  *templparser.SourceMap // Maps the generated code to the .templ file.
+}
+
+// newTemplMap returns the map of the Go file goFilePath to its .templ file.
+// Returns false if goFilePath isn't generated by templ, the .templ file
+// can't be generated or the Go file is out of date.
+func newTemplMap(goFilePath string) (*templMap, bool) {
+	path, ok := templSourcePath(goFilePath)
+	if !ok {
+		return nil, false
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	goCode, err := os.ReadFile(goFilePath)
+	if err != nil {
+		return nil, false
+	}
+	tf, err := templparser.ParseString(string(src))
+	if err != nil {
+		log.Verbose("parsing templ file",
+			slog.String("file", path), slog.String("err", err.Error()))
+		return nil, false
+	}
+	var generated bytes.Buffer
+	out, err := generator.Generate(tf, &generated)
+	if err != nil {
+		log.Verbose("generating templ file",
+			slog.String("file", path), slog.String("err", err.Error()))
+		return nil, false
+	}
+
+	m := &templMap{
+		path:      path,
+		lines:     strings.Split(string(src), "\n"),
+		sourceMap: out.SourceMap,
+	}
+	offsets, lits := goTokens(goCode)
+	genOffsets, genLits := goTokens(generated.Bytes())
+	if !slices.Equal(lits, genLits) {
+		log.Verbose("templ generated file out of date", slog.String("file", goFilePath))
+		return nil, false
+	}
+	m.offsets, m.genOffsets = offsets, genOffsets
+	m.genFile = token.NewFileSet().AddFile(path, -1, generated.Len())
+	m.genFile.SetLinesForContent(generated.Bytes())
+	return m, true
+}
+
+// goTokens returns the offsets and the literal values of all tokens of src
+// except semicolons, which depend on formatting, and string literals,
+// which include the path of the .templ file depending on where templ was run.
+func goTokens(src []byte) (offsets []int, lits []string) {
+	var s scanner.Scanner
+	f := token.NewFileSet().AddFile("", -1, len(src))
+	s.Init(f, src, nil, 0)
+	for {
+		pos, tok, lit := s.Scan()
+		switch tok {
+		case token.EOF:
+			return offsets, lits
+		case token.SEMICOLON:
+			continue
+		case token.STRING:
+			lit = ""
+		case token.IDENT, token.INT, token.FLOAT, token.IMAG, token.CHAR:
+		default:
+			lit = tok.String()
+		}
+		offsets = append(offsets, f.Offset(pos))
+		lits = append(lits, lit)
+	}
+}
+
+// position returns the position in the .templ file corresponding to pos
+// in the Go file. Returns pos unchanged if it's not part of Go code
+// written in the .templ file.
+func (m *templMap) position(pos token.Position) token.Position {
+	i, ok := slices.BinarySearch(m.offsets, pos.Offset)
+	if !ok {
+		return pos
+	}
+	gen := m.genFile.Position(m.genFile.Pos(m.genOffsets[i]))
+	src, ok := m.sourceMap.TargetLinesToSource[uint32(gen.Line-1)][uint32(gen.Column-1)]
+	if !ok {
+		return pos
+	}
+	return token.Position{
+		Filename: m.path,
+		Offset:   int(src.Index),
+		Line:     int(src.Line) + 1,
+		Column:   int(src.Col) + 1,
+	}
+}
+
+// leadingComments returns the lines of the comment immediately above
+// line in the .templ file. Like findLeadingComments it keeps directives as is.
+func (m *templMap) leadingComments(line int) []string {
+	start := line - 1 // Index of the line.
+	for start > 0 && strings.HasPrefix(strings.TrimSpace(m.lines[start-1]), "//") {
+		start--
+	}
+	var comments []string
+	for _, l := range m.lines[start : line-1] {
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(l, directivePrefix) {
+			comments = append(comments, l) // Directives are parsed later.
+			continue
+		}
+		comments = append(comments, strings.TrimSpace(strings.TrimPrefix(l, "//")))
+	}
+	return comments
+}
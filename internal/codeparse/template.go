@@ -0,0 +1,246 @@
+package codeparse
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template/parse"
+
+	"github.com/romshark/toki/internal/log"
+
+	tik "github.com/romshark/tik/tik-go"
+)
+
+// TemplateFuncName is the name of the template function provided by FuncMap
+// of the generated bundle package. It's equivalent to Reader.String:
+//
+//	{{ t "You have {# messages}" .Count }}
+const TemplateFuncName = "t"
+
+// ParseTemplates adds the texts of all html/template and text/template files
+// matching the glob patterns (see filepath.Match) relative to dir to scan.
+// Calls to TemplateFuncName must pass the TIK as a string literal
+// followed by one argument per placeholder.
+// Comments on the lines immediately above a call are passed on to translators:
+//
+//	{{/* Shown on the inbox page. */}}
+//	{{/* toki:maxlen 30 */}}
+//	{{ t "You have {# messages}" .Count }}
+func (p *Parser) ParseTemplates(
+	scan *Scan, dir string, patterns []string, trimPathBase string,
+) error {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return fmt.Errorf("template pattern %q: %w", pattern, err)
+		}
+		files = append(files, matches...)
+	}
+	slices.Sort(files)
+	files = slices.Compact(files)
+
+	for _, path := range files {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("determining template file path: %w", err)
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading template file: %w", err)
+		}
+		var fileDomain *Domain
+		if scan.Domains != nil {
+			fileDomain = scan.Domains.ForDir(filepath.Dir(path))
+		}
+		pkg, err := filepath.Rel(dir, filepath.Dir(path))
+		if err != nil {
+			pkg = filepath.Dir(path)
+		}
+		scan.FilesTraversed.Add(1)
+		for _, c := range p.collectTemplateTexts(path, string(src)) {
+			p.addCall(c, fileDomain, filepath.ToSlash(pkg), trimPathBase, "", false, scan)
+		}
+		log.Verbose("traversed template", slog.String("file", path))
+	}
+	return nil
+}
+
+// collectTemplateTexts extracts all calls to TemplateFuncName
+// from the template file at path with contents src.
+func (p *Parser) collectTemplateTexts(path, src string) []call {
+	position := func(offset parse.Pos) token.Position {
+		before := src[:offset]
+		return token.Position{
+			Filename: path,
+			Offset:   int(offset),
+			Line:     strings.Count(before, "\n") + 1,
+			Column:   int(offset) - strings.LastIndexByte(before, '\n'),
+		}
+	}
+
+	tree := parse.New(path)
+	tree.Mode = parse.ParseComments | parse.SkipFuncCheck
+	trees := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(src, "", "", trees); err != nil {
+		pos := token.Position{Filename: path}
+		return []call{{Pos: pos, errs: []SourceError{{
+			Position: pos, Err: fmt.Errorf("parsing template: %w", err),
+		}}}}
+	}
+
+	// comments holds the lines of all comments by the line they end on.
+	comments := make(map[int][]string)
+	type templateCall struct {
+		cmd   *parse.CommandNode
+		piped bool // The result of the previous command is passed as last argument.
+	}
+	var tCalls []templateCall
+	for _, name := range slices.Sorted(maps.Keys(trees)) {
+		walkTemplate(trees[name].Root, func(n parse.Node) {
+			switch n := n.(type) {
+			case *parse.CommentNode:
+				text := strings.TrimSuffix(strings.TrimPrefix(n.Text, "/*"), "*/")
+				end := position(n.Pos).Line + strings.Count(n.Text, "\n")
+				comments[end] = strings.Split(text, "\n")
+			case *parse.PipeNode:
+				for i, cmd := range n.Cmds {
+					if id, ok := cmd.Args[0].(*parse.IdentifierNode); ok &&
+						id.Ident == TemplateFuncName {
+						tCalls = append(tCalls, templateCall{cmd: cmd, piped: i > 0})
+					}
+				}
+			}
+		})
+	}
+
+	calls := make([]call, 0, len(tCalls))
+	for _, tc := range tCalls {
+		c := call{Func: FuncTypeString, Pos: position(tc.cmd.Pos)}
+		onSrcErr := func(pos token.Position, err error) {
+			c.errs = append(c.errs, SourceError{Position: pos, Err: fmt.Errorf("TIK: %w", err)})
+		}
+		tikVal, ok := p.parseTemplateTIK(tc.cmd, tc.piped, position, onSrcErr)
+		if ok {
+			c.TIK = tikVal.Raw
+			c.Comments = leadingTemplateComments(comments, c.Pos.Line)
+		}
+		calls = append(calls, c)
+	}
+	return calls
+}
+
+// parseTemplateTIK parses the TIK of the call cmd and checks that there's
+// an argument for every placeholder. The types of the arguments are unknown.
+// piped is true if cmd receives the result of the previous command
+// of its pipeline as last argument.
+func (p *Parser) parseTemplateTIK(
+	cmd *parse.CommandNode, piped bool, position func(parse.Pos) token.Position,
+	onSrcErr FnOnSrcErr,
+) (tk tik.TIK, ok bool) {
+	pos := position(cmd.Pos)
+	if len(cmd.Args) < 2 {
+		onSrcErr(pos, errors.New("missing TIK argument"))
+		return tk, false
+	}
+	str, isStr := cmd.Args[1].(*parse.StringNode)
+	if !isStr {
+		onSrcErr(position(cmd.Args[1].Position()), errors.New("not a string constant"))
+		return tk, false
+	}
+	pos = position(str.Pos)
+	tk, err := p.tikParser.Parse(str.Text)
+	if err != nil {
+		onSrcErr(pos, err)
+		return tk, false
+	}
+
+	args := len(cmd.Args) - 2
+	if piped {
+		args++
+	}
+	placeholders := 0
+	for range tk.Placeholders() {
+		placeholders++
+	}
+	ok = true
+	for i, placeholder := range tk.Placeholders() {
+		if i >= args {
+			onSrcErr(pos, fmt.Errorf("missing argument %d for placeholder (%s)",
+				i, placeholder.Type.String()))
+			ok = false
+		}
+	}
+	for i := placeholders; i < args; i++ {
+		onSrcErr(pos, fmt.Errorf("arg %d doesn't match any TIK placeholder", i))
+		ok = false
+	}
+	return tk, ok
+}
+
+// leadingTemplateComments returns the lines of the comments ending on the lines
+// immediately above line. Lines starting with "toki:" are directives.
+func leadingTemplateComments(comments map[int][]string, line int) []string {
+	var lines []string
+	for l := line - 1; ; {
+		c, ok := comments[l]
+		if !ok {
+			break
+		}
+		lines = append(slices.Clone(c), lines...)
+		l -= len(c)
+	}
+	for i, l := range lines {
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(l, strings.TrimPrefix(directivePrefix, "//")) {
+			l = "//" + l
+		}
+		lines[i] = l
+	}
+	return lines
+}
+
+// walkTemplate calls fn for n and all of its descendants.
+func walkTemplate(n parse.Node, fn func(parse.Node)) {
+	fn(n)
+	switch n := n.(type) {
+	case *parse.ListNode:
+		for _, c := range n.Nodes {
+			walkTemplate(c, fn)
+		}
+	case *parse.ActionNode:
+		walkTemplate(n.Pipe, fn)
+	case *parse.PipeNode:
+		for _, c := range n.Cmds {
+			walkTemplate(c, fn)
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			walkTemplate(a, fn)
+		}
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.TemplateNode:
+		if n.Pipe != nil {
+			walkTemplate(n.Pipe, fn)
+		}
+	}
+}
+
+func walkBranch(n *parse.BranchNode, fn func(parse.Node)) {
+	walkTemplate(n.Pipe, fn)
+	walkTemplate(n.List, fn)
+	if n.ElseList != nil {
+		walkTemplate(n.ElseList, fn)
+	}
+}
@@ -0,0 +1,76 @@
+package codeparse
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Workspace is a go.work multi-module workspace.
+type Workspace struct {
+	// File is the absolute path to the go.work file.
+	File string
+
+	// Modules holds the absolute directory paths of all used modules.
+	Modules []string
+}
+
+// Dir returns the absolute path of the workspace root directory.
+func (w *Workspace) Dir() string { return filepath.Dir(w.File) }
+
+// FindWorkspace returns the go.work workspace dir belongs to.
+// Returns nil and no error if dir isn't part of a workspace.
+func FindWorkspace(env []string, dir string) (*Workspace, error) {
+	cmd := exec.Command("go", "env", "GOWORK")
+	cmd.Env = env
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("determining GOWORK: %w", err)
+	}
+	path := string(bytes.TrimSpace(out))
+	if path == "" || path == "off" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading go.work: %w", err)
+	}
+	f, err := modfile.ParseWork(path, data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parsing go.work: %w", err)
+	}
+
+	w := &Workspace{File: path, Modules: make([]string, len(f.Use))}
+	for i, u := range f.Use {
+		dir := u.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(w.Dir(), dir)
+		}
+		w.Modules[i] = filepath.Clean(dir)
+	}
+	return w, nil
+}
+
+// goEnv returns the values of the go environment variables names
+// omitting empty ones.
+func goEnv(env []string, names ...string) ([]string, error) {
+	cmd := exec.Command("go", append([]string{"env"}, names...)...)
+	cmd.Env = env
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("determining %v: %w", names, err)
+	}
+	var values []string
+	for line := range bytes.Lines(out) {
+		if v := string(bytes.TrimSpace(line)); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
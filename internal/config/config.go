@@ -5,7 +5,9 @@ import (
 	"flag"
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/text/language"
 )
@@ -14,6 +16,8 @@ type ConfigWebedit struct {
 	Host          string
 	BundlePkgPath string
 	DontOpen      bool
+	Workspace     string
+	Templates     []string // Template glob patterns of the bundle.
 }
 
 type ConfigGenerate struct {
@@ -24,11 +28,110 @@ type ConfigGenerate struct {
 	JSON            bool
 	QuietMode       bool
 	VerboseMode     bool
-	BundlePkgPath   string
+	Bundles         []ConfigBundle
 	RequireComplete bool
+	Check           bool
+	Watch           bool
+	Workspace       string
+	Format          string // Findings output format, empty for none.
+
+	// Interpreter enables generating LoadCatalog interpreting ARB catalogs
+	// at runtime.
+	Interpreter bool
+
+	// Fallbacks overrides the fallback locales of catalogs by locale
+	// which default to their CLDR parent locales.
+	Fallbacks map[language.Tag][]language.Tag
+
+	// Templates are glob patterns of html/template and text/template files
+	// relative to ModPath scanned for every bundle.
+	Templates []string
+
+	// Builds are the build configurations the source code is scanned in.
+	// The source code is scanned in the build configuration of the environment
+	// if empty.
+	Builds []Build
+}
+
+// Build is a build configuration. Empty fields default to the environment.
+type Build struct {
+	GOOS   string
+	GOARCH string
+	Tags   []string
+}
+
+// ConfigBundle is a bundle package generated by command "generate".
+type ConfigBundle struct {
+	PkgPath string
+
+	// Translations are the translation locales of this bundle only
+	// in addition to ConfigGenerate.Translations.
+	Translations []language.Tag
+
+	// Templates are the template glob patterns of this bundle only
+	// in addition to ConfigGenerate.Templates.
+	Templates []string
 }
 
-var ErrLocaleNotBCP47 = errors.New("must be a valid non-und BCP 47 locale")
+type ConfigPrune struct {
+	ModPath       string
+	BundlePkgPath string
+	OlderThan     time.Duration
+}
+
+type ConfigStats struct {
+	ModPath   string
+	Bundles   []ConfigBundle
+	Format    string
+	Workspace string
+
+	// Templates are glob patterns of html/template and text/template files
+	// relative to ModPath scanned for every bundle.
+	Templates []string
+
+	// Builds are the build configurations the source code is scanned in.
+	Builds []Build
+}
+
+// Output formats supported by command "stats".
+const (
+	FormatText     = "text"
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// Findings output formats supported by commands "generate" and "lint".
+const (
+	FormatSARIF      = "sarif"
+	FormatGitHub     = "github"
+	FormatCheckstyle = "checkstyle"
+)
+
+// Modes of operation in go.work workspaces.
+const (
+	// WorkspaceOff ignores the workspace and only scans the module (default).
+	WorkspaceOff = "off"
+
+	// WorkspaceShared scans all workspace modules into a single shared bundle.
+	WorkspaceShared = "shared"
+
+	// WorkspacePerModule generates a separate bundle for every workspace module.
+	WorkspacePerModule = "per-module"
+)
+
+var (
+	ErrLocaleNotBCP47    = errors.New("must be a valid non-und BCP 47 locale")
+	ErrMissingOlderThan  = errors.New("missing required argument older-than")
+	ErrInvalidOlderThan  = errors.New("must be a positive duration such as 72h or 30d")
+	ErrInvalidFormat     = errors.New("must be either of: [text,json,markdown]")
+	ErrInvalidWorkspace  = errors.New("must be either of: [off,shared,per-module]")
+	ErrInvalidLintFormat = errors.New("must be either of: [sarif,github,checkstyle]")
+	ErrBundleAndBundles  = errors.New("bundle and bundles are mutually exclusive")
+	ErrBundlePathEmpty   = errors.New("bundle path must not be empty")
+	ErrInvalidBuild      = errors.New(
+		"must be either of: [GOOS/GOARCH, :tag,tag, GOOS/GOARCH:tag,tag]")
+	ErrInvalidFallback = errors.New("must be formatted as locale=fallback,fallback")
+)
 
 func ParseCLIArgsWebedit(osArgs []string) (*ConfigWebedit, error) {
 	c := &ConfigWebedit{}
@@ -39,20 +142,173 @@ func ParseCLIArgsWebedit(osArgs []string) (*ConfigWebedit, error) {
 		"HTTP server host address")
 	cli.StringVar(&c.BundlePkgPath, "b", "tokibundle",
 		"path to generated Go bundle package")
+	workspaceFlag(cli, &c.Workspace)
+
+	if err := cli.Parse(osArgs[2:]); err != nil {
+		return nil, fmt.Errorf("parsing: %w", err)
+	}
+
+	f, err := ReadFile(".")
+	if err != nil {
+		return nil, err
+	}
+	if f != nil {
+		set := flagsSet(cli)
+		if !set["host"] && f.Webedit.Host != "" {
+			c.Host = f.Webedit.Host
+		}
+		if !set["b"] && f.Bundle != "" {
+			c.BundlePkgPath = f.Bundle
+		}
+		if !set["workspace"] && f.Workspace != "" {
+			c.Workspace = f.Workspace
+		}
+		c.Templates = f.templates(c.BundlePkgPath)
+	}
+
+	if err := validateWorkspace(c.Workspace); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// ParseCLIArgsPrune parses CLI arguments for command "prune"
+func ParseCLIArgsPrune(osArgs []string) (*ConfigPrune, error) {
+	c := &ConfigPrune{}
+
+	var olderThan string
+
+	cli := flag.NewFlagSet(osArgs[0], flag.ExitOnError)
+	cli.StringVar(&c.ModPath, "m", ".", "path to Go module")
+	cli.StringVar(&c.BundlePkgPath, "b", "tokibundle",
+		"path to generated Go bundle package relative to module path (-m)")
+	cli.StringVar(&olderThan, "older-than", "",
+		"removes archived obsolete messages older than the given duration "+
+			"(for example 72h or 30d)")
 
 	if err := cli.Parse(osArgs[2:]); err != nil {
 		return nil, fmt.Errorf("parsing: %w", err)
 	}
 
+	f, err := ReadFile(c.ModPath)
+	if err != nil {
+		return nil, err
+	}
+	if f != nil && !flagsSet(cli)["b"] && f.Bundle != "" {
+		c.BundlePkgPath = f.Bundle
+	}
+
+	if olderThan == "" {
+		return nil, ErrMissingOlderThan
+	}
+	if c.OlderThan, err = parseDuration(olderThan); err != nil {
+		return nil, fmt.Errorf("argument older-than=%q: %w", olderThan, err)
+	}
+
 	return c, nil
 }
 
+// ParseCLIArgsStats parses CLI arguments for command "stats"
+func ParseCLIArgsStats(osArgs []string) (*ConfigStats, error) {
+	c := &ConfigStats{}
+
+	var bundles strArray
+	var templates strArray
+	var builds strArray
+
+	cli := flag.NewFlagSet(osArgs[0], flag.ExitOnError)
+	cli.StringVar(&c.ModPath, "m", ".", "path to Go module")
+	cli.Var(&bundles, "b",
+		"path to generated Go bundle package relative to module path (-m) "+
+			"(multiple are accepted, defaults to tokibundle)")
+	cli.StringVar(&c.Format, "format", FormatText,
+		"output format, either of: [text,json,markdown]")
+	workspaceFlag(cli, &c.Workspace)
+	cli.Var(&templates, "templates",
+		"glob pattern of html/template and text/template files relative to "+
+			"module path (-m) (multiple are accepted)")
+	cli.Var(&builds, "build",
+		"build configuration to scan the source code in, either of: "+
+			"[GOOS/GOARCH, :tag,tag, GOOS/GOARCH:tag,tag] "+
+			"(multiple are accepted, defaults to the environment)")
+
+	if err := cli.Parse(osArgs[2:]); err != nil {
+		return nil, fmt.Errorf("parsing: %w", err)
+	}
+
+	f, err := ReadFile(c.ModPath)
+	if err != nil {
+		return nil, err
+	}
+	if f != nil {
+		set := flagsSet(cli)
+		if !set["b"] {
+			if c.Bundles, err = f.bundles(); err != nil {
+				return nil, err
+			}
+		}
+		if !set["workspace"] && f.Workspace != "" {
+			c.Workspace = f.Workspace
+		}
+		if !set["templates"] {
+			templates = f.Templates
+		}
+		if !set["build"] {
+			c.Builds = f.builds()
+		}
+	}
+	c.Templates = templates
+
+	if c.Builds, err = appendBuilds(c.Builds, builds); err != nil {
+		return nil, err
+	}
+	c.Bundles = appendBundles(c.Bundles, bundles)
+
+	if err := validateWorkspace(c.Workspace); err != nil {
+		return nil, err
+	}
+
+	switch c.Format {
+	case FormatText, FormatJSON, FormatMarkdown:
+	default:
+		return nil, fmt.Errorf("argument format=%q: %w", c.Format, ErrInvalidFormat)
+	}
+
+	return c, nil
+}
+
+// parseDuration parses a time.Duration additionally accepting days (e.g. "30d").
+func parseDuration(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseUint(days, 10, 32)
+		if err != nil {
+			return 0, ErrInvalidOlderThan
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, ErrInvalidOlderThan
+		}
+	}
+	if d <= 0 {
+		return 0, ErrInvalidOlderThan
+	}
+	return d, nil
+}
+
 // ParseCLIArgsGenerate parses CLI arguments for command "generate"
 func ParseCLIArgsGenerate(osArgs []string) (*ConfigGenerate, error) {
 	c := &ConfigGenerate{}
 
 	var locale string
 	var translations strArray
+	var bundles strArray
+	var templates strArray
+	var builds strArray
+	var fallbacks strArray
 
 	cli := flag.NewFlagSet(osArgs[0], flag.ExitOnError)
 	cli.StringVar(&locale, "l", "",
@@ -66,15 +322,97 @@ func ParseCLIArgsGenerate(osArgs []string) (*ConfigGenerate, error) {
 	cli.BoolVar(&c.JSON, "json", false, "enables JSON output")
 	cli.BoolVar(&c.QuietMode, "q", false, "disable all console logging")
 	cli.BoolVar(&c.VerboseMode, "v", false, "enables verbose console logging")
-	cli.StringVar(&c.BundlePkgPath, "b", "tokibundle",
-		"path to generated Go bundle package relative to module path (-m)")
+	cli.Var(&bundles, "b",
+		"path to generated Go bundle package relative to module path (-m) "+
+			"(multiple are accepted, defaults to tokibundle)")
 	cli.BoolVar(&c.RequireComplete, "require-complete", false,
 		"fails the command if any active catalog has a completeness < 1.0 (under 100%)")
+	cli.BoolVar(&c.Check, "check", false,
+		"doesn't write any files, instead prints a diff and fails the command "+
+			"if any generated file is out of date")
+	cli.BoolVar(&c.Watch, "watch", false,
+		"regenerates the bundle whenever source files, domain files or catalogs change")
+	workspaceFlag(cli, &c.Workspace)
+	cli.StringVar(&c.Format, "format", "",
+		"writes all findings to stdout, either of: [sarif,github,checkstyle]")
+	cli.Var(&templates, "templates",
+		"glob pattern of html/template and text/template files relative to "+
+			"module path (-m) (multiple are accepted)")
+	cli.Var(&fallbacks, "fallback",
+		"fallback locales of a catalog in order formatted as locale=fallback,fallback "+
+			"(multiple are accepted, defaults to the CLDR parent locales)")
+	cli.BoolVar(&c.Interpreter, "interpreter", false,
+		"generates LoadCatalog for loading ARB catalogs at runtime without regeneration")
+	cli.Var(&builds, "build",
+		"build configuration to scan the source code in, either of: "+
+			"[GOOS/GOARCH, :tag,tag, GOOS/GOARCH:tag,tag] "+
+			"(multiple are accepted, defaults to the environment)")
 
 	if err := cli.Parse(osArgs[2:]); err != nil {
 		return nil, fmt.Errorf("parsing: %w", err)
 	}
 
+	// Apply settings from the project configuration file unless overridden by flags.
+	f, err := ReadFile(c.ModPath)
+	if err != nil {
+		return nil, err
+	}
+	if f != nil {
+		set := flagsSet(cli)
+		if !set["l"] {
+			locale = f.Locale
+		}
+		if !set["t"] {
+			translations = f.Translations
+		}
+		if !set["b"] {
+			if c.Bundles, err = f.bundles(); err != nil {
+				return nil, err
+			}
+		}
+		if !set["trimpath"] && f.TrimPath != nil {
+			c.TrimPath = *f.TrimPath
+		}
+		if !set["require-complete"] && f.RequireComplete != nil {
+			c.RequireComplete = *f.RequireComplete
+		}
+		if !set["workspace"] && f.Workspace != "" {
+			c.Workspace = f.Workspace
+		}
+		if !set["templates"] {
+			templates = f.Templates
+		}
+		if !set["build"] {
+			c.Builds = f.builds()
+		}
+		if !set["interpreter"] && f.Interpreter != nil {
+			c.Interpreter = *f.Interpreter
+		}
+		if !set["fallback"] {
+			fallbacks = f.fallbacks()
+		}
+	}
+	c.Templates = templates
+
+	if c.Builds, err = appendBuilds(c.Builds, builds); err != nil {
+		return nil, err
+	}
+
+	for _, s := range fallbacks {
+		locale, chain, err := parseFallback(s)
+		if err != nil {
+			return nil, err
+		}
+		if c.Fallbacks == nil {
+			c.Fallbacks = make(map[language.Tag][]language.Tag)
+		}
+		c.Fallbacks[locale] = chain
+	}
+
+	if err := validateWorkspace(c.Workspace); err != nil {
+		return nil, err
+	}
+
 	if locale != "" {
 		var err error
 		c.Locale, err = language.Parse(locale)
@@ -90,24 +428,139 @@ func ParseCLIArgsGenerate(osArgs []string) (*ConfigGenerate, error) {
 		}
 	}
 
+	switch c.Format {
+	case "", FormatSARIF, FormatGitHub, FormatCheckstyle:
+	default:
+		return nil, fmt.Errorf("argument format=%q: %w", c.Format, ErrInvalidLintFormat)
+	}
+
+	if c.Translations, err = parseTranslations(translations); err != nil {
+		return nil, err
+	}
+
+	c.Bundles = appendBundles(c.Bundles, bundles)
+
+	return c, nil
+}
+
+// appendBundles appends the bundle packages at pkgPaths to bundles
+// ignoring duplicates. Returns the default bundle package if there are none.
+func appendBundles(bundles []ConfigBundle, pkgPaths []string) []ConfigBundle {
+	for _, p := range pkgPaths {
+		if !slices.ContainsFunc(bundles, func(b ConfigBundle) bool {
+			return b.PkgPath == p
+		}) {
+			bundles = append(bundles, ConfigBundle{PkgPath: p})
+		}
+	}
+	if len(bundles) == 0 {
+		bundles = []ConfigBundle{{PkgPath: "tokibundle"}}
+	}
+	return bundles
+}
+
+// appendBuilds parses and appends the build configurations args to builds.
+func appendBuilds(builds []Build, args []string) ([]Build, error) {
+	for _, s := range args {
+		b, err := parseBuild(s)
+		if err != nil {
+			return nil, err
+		}
+		builds = append(builds, b)
+	}
+	return builds, nil
+}
+
+// parseBuild parses a build configuration formatted as GOOS/GOARCH:tag,tag
+// where either the platform or the tags may be omitted.
+func parseBuild(s string) (Build, error) {
+	platform, tags, hasTags := strings.Cut(s, ":")
+	var b Build
+	if platform != "" {
+		var ok bool
+		b.GOOS, b.GOARCH, ok = strings.Cut(platform, "/")
+		if !ok || b.GOOS == "" || b.GOARCH == "" {
+			return Build{}, fmt.Errorf("argument build=%q: %w", s, ErrInvalidBuild)
+		}
+	}
+	if hasTags {
+		for tag := range strings.SplitSeq(tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				b.Tags = append(b.Tags, tag)
+			}
+		}
+	}
+	if b.GOOS == "" && len(b.Tags) == 0 {
+		return Build{}, fmt.Errorf("argument build=%q: %w", s, ErrInvalidBuild)
+	}
+	return b, nil
+}
+
+// parseFallback parses the fallback locales of a catalog
+// formatted as locale=fallback,fallback.
+func parseFallback(s string) (locale language.Tag, chain []language.Tag, err error) {
+	l, fallbacks, ok := strings.Cut(s, "=")
+	if !ok || strings.TrimSpace(l) == "" {
+		return locale, nil, fmt.Errorf("argument fallback=%q: %w", s, ErrInvalidFallback)
+	}
+	if locale, err = language.Parse(strings.TrimSpace(l)); err != nil {
+		return locale, nil, fmt.Errorf("argument fallback=%q: %w: %w",
+			s, ErrLocaleNotBCP47, err)
+	}
+	for f := range strings.SplitSeq(fallbacks, ",") {
+		if f = strings.TrimSpace(f); f == "" {
+			continue
+		}
+		t, err := language.Parse(f)
+		if err != nil {
+			return locale, nil, fmt.Errorf("argument fallback=%q: %w: %w",
+				s, ErrLocaleNotBCP47, err)
+		}
+		chain = append(chain, t)
+	}
+	return locale, chain, nil
+}
+
+// parseTranslations parses the translation locales removing duplicates.
+func parseTranslations(translations []string) ([]language.Tag, error) {
+	translations = slices.Clone(translations)
 	slices.Sort(translations)
 	translations = slices.Compact(translations)
 	// Ignore any duplicate of locale in translations.
 	// It will be filtered out later during missing catalog detection.
 	// We don't do it here because -l is optional when the bundle package exists.
-	c.Translations = make([]language.Tag, len(translations))
+	tags := make([]language.Tag, len(translations))
 	for i, s := range translations {
 		var err error
-		c.Translations[i], err = language.Parse(s)
+		tags[i], err = language.Parse(s)
 		if err != nil {
 			return nil, fmt.Errorf("argument t=%q: %w: %w", s, ErrLocaleNotBCP47, err)
 		}
-		if c.Translations[i] == language.Und {
+		if tags[i] == language.Und {
 			return nil, fmt.Errorf("argument t=%q: %w: is und", s, ErrLocaleNotBCP47)
 		}
 	}
+	return tags, nil
+}
 
-	return c, nil
+func workspaceFlag(cli *flag.FlagSet, v *string) {
+	cli.StringVar(v, "workspace", WorkspaceOff,
+		"mode of operation in go.work workspaces, either of: [off,shared,per-module]")
+}
+
+func validateWorkspace(s string) error {
+	switch s {
+	case WorkspaceOff, WorkspaceShared, WorkspacePerModule:
+		return nil
+	}
+	return fmt.Errorf("argument workspace=%q: %w", s, ErrInvalidWorkspace)
+}
+
+// flagsSet returns the names of all flags explicitly set on the command line.
+func flagsSet(cli *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	cli.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
 }
 
 type strArray []string
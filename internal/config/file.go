@@ -0,0 +1,173 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// FileName is the name of the project configuration file expected at the module root.
+const FileName = ".toki.yml"
+
+var ErrFileInvalid = errors.New("invalid " + FileName + " file")
+
+// File is the project configuration file.
+// Any setting in this file is overridden by its CLI flag counterpart.
+type File struct {
+	// Locale is the default locale of the original source code texts (-l).
+	Locale string `yaml:"locale"`
+
+	// Translations lists the translation locales (-t).
+	Translations []string `yaml:"translations"`
+
+	// Bundle is the path to the generated Go bundle package (-b).
+	Bundle string `yaml:"bundle"`
+
+	// Bundles lists multiple independent bundle packages (multiple -b),
+	// each optionally with its own translation locales.
+	// Only supported by commands "generate" and "lint".
+	Bundles []FileBundle `yaml:"bundles"`
+
+	// TrimPath enables source code path trimming (-trimpath).
+	TrimPath *bool `yaml:"trimpath"`
+
+	// RequireComplete fails generate and lint if any catalog is incomplete
+	// (-require-complete).
+	RequireComplete *bool `yaml:"require-complete"`
+
+	// Workspace is the mode of operation in go.work workspaces (-workspace).
+	Workspace string `yaml:"workspace"`
+
+	// Templates lists glob patterns of html/template and text/template files
+	// relative to the module root scanned for every bundle (multiple -templates).
+	Templates []string `yaml:"templates"`
+
+	// Builds lists the build configurations the source code is scanned in
+	// (multiple -build).
+	Builds []FileBuild `yaml:"builds"`
+
+	// Interpreter enables generating LoadCatalog for loading ARB catalogs
+	// at runtime (-interpreter).
+	Interpreter *bool `yaml:"interpreter"`
+
+	// Fallbacks lists the fallback locales of catalogs by locale
+	// (multiple -fallback).
+	Fallbacks map[string][]string `yaml:"fallbacks"`
+
+	Webedit FileWebedit `yaml:"webedit"`
+}
+
+// FileBuild is a build configuration in the project configuration file.
+// Empty fields default to the environment.
+type FileBuild struct {
+	GOOS   string   `yaml:"goos"`
+	GOARCH string   `yaml:"goarch"`
+	Tags   []string `yaml:"tags"`
+}
+
+// FileBundle is a bundle package in the project configuration file.
+type FileBundle struct {
+	// Path is the path to the generated Go bundle package.
+	Path string `yaml:"path"`
+
+	// Translations lists the translation locales of this bundle only
+	// in addition to the global translation locales.
+	Translations []string `yaml:"translations"`
+
+	// Templates lists the template glob patterns of this bundle only
+	// in addition to the global template glob patterns.
+	Templates []string `yaml:"templates"`
+}
+
+// FileWebedit holds the settings for command "webedit".
+type FileWebedit struct {
+	// Host is the HTTP server host address (-host).
+	Host string `yaml:"host"`
+}
+
+// ReadFile reads the project configuration file in dir.
+// Returns nil and no error if the file doesn't exist.
+func ReadFile(dir string) (*File, error) {
+	path := filepath.Join(dir, FileName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", FileName, err)
+	}
+	var f File
+	// Unknown keys are rejected to catch misspelled settings.
+	dec := yaml.NewDecoder(bytes.NewReader(data), yaml.DisallowUnknownField())
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %s", ErrFileInvalid, yaml.FormatError(err, false, false))
+	}
+	return &f, nil
+}
+
+// bundles returns the bundle packages configured in f.
+// Returns nil if none are configured.
+func (f *File) bundles() ([]ConfigBundle, error) {
+	switch {
+	case f.Bundle != "" && len(f.Bundles) > 0:
+		return nil, fmt.Errorf("%w: %w", ErrFileInvalid, ErrBundleAndBundles)
+	case f.Bundle != "":
+		return []ConfigBundle{{PkgPath: f.Bundle}}, nil
+	}
+	bundles := make([]ConfigBundle, len(f.Bundles))
+	for i, b := range f.Bundles {
+		if b.Path == "" {
+			return nil, fmt.Errorf("%w: bundles[%d]: %w",
+				ErrFileInvalid, i, ErrBundlePathEmpty)
+		}
+		translations, err := parseTranslations(b.Translations)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bundles[%d]: %w", ErrFileInvalid, i, err)
+		}
+		bundles[i] = ConfigBundle{
+			PkgPath:      b.Path,
+			Translations: translations,
+			Templates:    b.Templates,
+		}
+	}
+	return bundles, nil
+}
+
+// builds returns the build configurations configured in f.
+func (f *File) builds() []Build {
+	builds := make([]Build, len(f.Builds))
+	for i, b := range f.Builds {
+		builds[i] = Build(b)
+	}
+	return builds
+}
+
+// fallbacks returns Fallbacks formatted like -fallback arguments.
+func (f *File) fallbacks() []string {
+	fallbacks := make([]string, 0, len(f.Fallbacks))
+	for _, locale := range slices.Sorted(maps.Keys(f.Fallbacks)) {
+		fallbacks = append(fallbacks,
+			locale+"="+strings.Join(f.Fallbacks[locale], ","))
+	}
+	return fallbacks
+}
+
+// templates returns the template glob patterns of the bundle package
+// at path bundle.
+func (f *File) templates(bundle string) []string {
+	templates := slices.Clone(f.Templates)
+	for _, b := range f.Bundles {
+		if filepath.Clean(b.Path) == filepath.Clean(bundle) {
+			templates = append(templates, b.Templates...)
+		}
+	}
+	return templates
+}
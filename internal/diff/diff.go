@@ -0,0 +1,210 @@
+// Package diff provides a line-based unified diff.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Context is the number of unchanged lines around each change in a hunk.
+const Context = 3
+
+type opKind int8
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// op is a single line edit. a is the line index in the old text (equal and delete),
+// b is the line index in the new text (equal and insert).
+type op struct {
+	kind opKind
+	a, b int
+}
+
+// Unified returns the unified diff between old and new or nil if they're equal.
+func Unified(oldName, newName string, old, new []byte) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+	a, b := splitLines(string(old)), splitLines(string(new))
+	ops := edits(a, b)
+
+	var out bytes.Buffer
+	_, _ = fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+	for start := 0; start < len(ops); {
+		// Find the next change.
+		for start < len(ops) && ops[start].kind == opEqual {
+			start++
+		}
+		if start >= len(ops) {
+			break
+		}
+		// Extend the hunk until there's a gap of unchanged lines
+		// that's larger than twice the context.
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind == opEqual {
+				if i-end > 2*Context {
+					break
+				}
+				continue
+			}
+			end = i + 1
+		}
+		hunkStart := max(start-Context, 0)
+		hunkEnd := min(end+Context, len(ops))
+		writeHunk(&out, a, b, ops[hunkStart:hunkEnd])
+		start = hunkEnd
+	}
+	return out.Bytes()
+}
+
+func writeHunk(out *bytes.Buffer, a, b []string, ops []op) {
+	// Determine the line ranges covered by the hunk.
+	startA, startB := -1, -1
+	var countA, countB int
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			countA++
+			countB++
+		case opDelete:
+			countA++
+		case opInsert:
+			countB++
+		}
+		if startA == -1 && o.kind != opInsert {
+			startA = o.a
+		}
+		if startB == -1 && o.kind != opDelete {
+			startB = o.b
+		}
+	}
+	if startA == -1 {
+		startA = linePosBefore(ops, true)
+	} else {
+		startA++ // Line numbers are 1-based.
+	}
+	if startB == -1 {
+		startB = linePosBefore(ops, false)
+	} else {
+		startB++
+	}
+	_, _ = fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", startA, countA, startB, countB)
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			writeLine(out, ' ', a[o.a])
+		case opDelete:
+			writeLine(out, '-', a[o.a])
+		case opInsert:
+			writeLine(out, '+', b[o.b])
+		}
+	}
+}
+
+// linePosBefore returns the 1-based number of the line preceding an empty range.
+// For a pure insertion into the old text this is the line after which the
+// insertion happens, for a pure deletion it's the same for the new text.
+func linePosBefore(ops []op, old bool) int {
+	o := ops[0]
+	if old {
+		return o.a
+	}
+	return o.b
+}
+
+func writeLine(out *bytes.Buffer, prefix byte, line string) {
+	out.WriteByte(prefix)
+	out.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		out.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// edits computes the shortest edit script transforming a into b
+// using the Myers algorithm.
+func edits(a, b []string) []op {
+	n, m := len(a), len(b)
+	limit := n + m
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+
+	// trace[d] holds the furthest reaching x for diagonals -d..d after step d.
+	var trace [][]int
+	done := false
+	for d := 0; d <= limit && !done; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Move down (insertion).
+			} else {
+				x = v[offset+k-1] + 1 // Move right (deletion).
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+	}
+
+	// Backtrack from (n, m) to (0, 0).
+	get := func(d, k int) int { return trace[d][k+d] }
+	ops := make([]op, 0, max(n, m))
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && get(d-1, k-1) < get(d-1, k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := get(d-1, prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			ops = append(ops, op{kind: opEqual, a: x, b: y})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, op{kind: opInsert, a: x, b: y})
+		} else {
+			x--
+			ops = append(ops, op{kind: opDelete, a: x, b: y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x, y = x-1, y-1
+		ops = append(ops, op{kind: opEqual, a: x, b: y})
+	}
+
+	// Reverse to get the script in order.
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
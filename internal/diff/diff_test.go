@@ -0,0 +1,68 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/romshark/toki/internal/diff"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnified(t *testing.T) {
+	f := func(t *testing.T, old, new, expect string) {
+		t.Helper()
+		actual := diff.Unified("a", "b", []byte(old), []byte(new))
+		require.Equal(t, expect, string(actual))
+	}
+
+	t.Run("equal", func(t *testing.T) {
+		f(t, "x\ny\n", "x\ny\n", "")
+	})
+
+	t.Run("new file", func(t *testing.T) {
+		f(t, "", "x\ny\n", "--- a\n+++ b\n"+
+			"@@ -0,0 +1,2 @@\n"+
+			"+x\n"+
+			"+y\n")
+	})
+
+	t.Run("removed file", func(t *testing.T) {
+		f(t, "x\ny\n", "", "--- a\n+++ b\n"+
+			"@@ -1,2 +0,0 @@\n"+
+			"-x\n"+
+			"-y\n")
+	})
+
+	t.Run("change in the middle", func(t *testing.T) {
+		f(t,
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			"1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			"--- a\n+++ b\n"+
+				"@@ -2,7 +2,7 @@\n"+
+				" 2\n 3\n 4\n"+
+				"-5\n"+
+				"+five\n"+
+				" 6\n 7\n 8\n")
+	})
+
+	t.Run("separate hunks", func(t *testing.T) {
+		f(t,
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n",
+			"--- a\n+++ b\n"+
+				"@@ -1,4 +1,4 @@\n"+
+				"-1\n"+
+				"+one\n"+
+				" 2\n 3\n 4\n"+
+				"@@ -10,3 +10,4 @@\n"+
+				" 10\n 11\n 12\n"+
+				"+13\n")
+	})
+
+	t.Run("no newline at end of file", func(t *testing.T) {
+		f(t, "x", "y", "--- a\n+++ b\n"+
+			"@@ -1,1 +1,1 @@\n"+
+			"-x\n\\ No newline at end of file\n"+
+			"+y\n\\ No newline at end of file\n")
+	})
+}
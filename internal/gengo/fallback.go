@@ -0,0 +1,40 @@
+package gengo
+
+import (
+	"slices"
+
+	"golang.org/x/text/language"
+)
+
+// fallbackChain returns the locales of catalogs tried in order if a translation
+// is missing in the catalog of locale. It's either the override of locale
+// or its CLDR parent locales, always followed by the default locale.
+// Locales without a catalog are skipped.
+func (w *Writer) fallbackChain(locale language.Tag) []language.Tag {
+	hasCatalog := func(t language.Tag) bool {
+		for c := range w.scan.Catalogs.SeqRead() {
+			if c.ARB.Locale == t {
+				return true
+			}
+		}
+		return false
+	}
+
+	var chain []language.Tag
+	add := func(t language.Tag) {
+		if t != locale && hasCatalog(t) && !slices.Contains(chain, t) {
+			chain = append(chain, t)
+		}
+	}
+	if override, ok := w.fallbacks[locale]; ok {
+		for _, t := range override {
+			add(t)
+		}
+	} else {
+		for t := locale.Parent(); t != language.Und; t = t.Parent() {
+			add(t)
+		}
+	}
+	add(w.scan.DefaultLocale)
+	return chain
+}
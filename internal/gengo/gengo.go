@@ -30,12 +30,20 @@ type Writer struct {
 	i    int            // Current index in t.
 
 	translatorVar string
+
+	hash *perfectHash // Built on first use.
+
+	// fallbacks overrides the fallback locales by locale (see fallbackChain).
+	fallbacks map[language.Tag][]language.Tag
 }
 
-func NewWriter(tokiVersion string, scan *codeparse.Scan) *Writer {
+func NewWriter(
+	tokiVersion string, scan *codeparse.Scan, fallbacks map[language.Tag][]language.Tag,
+) *Writer {
 	return &Writer{
 		tokiVersion: tokiVersion,
 		scan:        scan,
+		fallbacks:   fallbacks,
 	}
 }
 
@@ -124,13 +132,73 @@ func (w *Writer) WritePackageBundle(
 	}
 	w.println("}}")
 
+	w.println("// catalogAppenders holds the message appenders of all catalogs by locale.")
+	w.println("var catalogAppenders = map[string]*[tikCount]func(b []byte, args ...any) []byte{")
+	for c := range w.scan.Catalogs.SeqRead() {
+		w.printf("%q: &appenders_%s,\n",
+			c.ARB.Locale.String(), localeToCatalogSuffix(c.ARB.Locale))
+	}
+	w.println("}")
+	w.println("// catalogFallbacks holds the fallback locales of all catalogs by locale.")
+	w.println("var catalogFallbacks = map[string][]language.Tag{")
+	for c := range w.scan.Catalogs.SeqRead() {
+		w.printf("%q: fallbacks_%s,\n",
+			c.ARB.Locale.String(), localeToCatalogSuffix(c.ARB.Locale))
+	}
+	w.println("}")
+
 	// TIKs
 	w.println("// TIKs")
 	w.printf("const (\n")
+	idByTIK := make(map[string]string)
 	for msg := range newMsgIter(w.scan, w.scan.DefaultLocale) {
 		w.printf("\t%s = `%s`\n", msg.ID, msg.TIK)
+		if _, ok := idByTIK[msg.TIK]; !ok {
+			idByTIK[msg.TIK] = msg.ID
+		}
 	}
 	w.println(`)`)
+
+	// Perfect hash tables of tikIndex.
+	h := w.perfectHash()
+	w.println("// tikCount is the number of TIK indexes (see tikIndex).")
+	w.printf("const tikCount = %d\n\n", h.Len())
+	w.println("// tikDisplacements holds the displacements of tikIndex by bucket.")
+	w.printf("var tikDisplacements = [%d]uint32{", len(h.Displacements))
+	for i, d := range h.Displacements {
+		if i%16 == 0 {
+			w.println()
+		}
+		w.printf("%d,", d)
+	}
+	w.println("\n}")
+	w.println("// tikTable holds the TIKs by their index.")
+	w.printf("var tikTable = [%d]TIK{\n", len(h.Table))
+	for i, tik := range h.Table {
+		if tik != "" {
+			w.printf("%d: %s,\n", i, idByTIK[tik])
+		}
+	}
+	w.println("}")
+	w.println("// tikFallback holds the indexes of TIKs tikHash can't tell apart.")
+	w.println("var tikFallback = map[TIK]int{")
+	for _, tik := range slices.Sorted(maps.Keys(h.Fallback)) {
+		w.printf("%s: %d,\n", idByTIK[tik], h.Fallback[tik])
+	}
+	w.println("}")
+}
+
+// perfectHash returns the perfect hash of all TIKs of the default locale.
+func (w *Writer) perfectHash() *perfectHash {
+	if w.hash == nil {
+		var tiks []string
+		for msg := range newMsgIter(w.scan, w.scan.DefaultLocale) {
+			tiks = append(tiks, msg.TIK)
+		}
+		h := newPerfectHash(tiks)
+		w.hash = &h
+	}
+	return w.hash
 }
 
 func goPlaygroundLocalesPkg(locale language.Tag) string {
@@ -151,6 +219,7 @@ func (w *Writer) WritePackageCatalog(
 	w.println("import (")
 	w.println(`"fmt"`)
 	w.println(`"io"`)
+	w.println(`"slices"`)
 	w.println(`"time"`)
 	w.println("")
 	w.printf("\tlocales \"github.com/go-playground/locales\"\n")
@@ -177,17 +246,30 @@ func (w *Writer) writeCatalogType(locale language.Tag) {
 	w.printf("%s = language.MustParse(%q)\n", localeVarName, locale.String())
 	w.println(")")
 
+	// Fallback locales.
+	w.printf("var fallbacks_%s = []language.Tag{", localeCatalogSuffix)
+	for _, t := range w.fallbackChain(locale) {
+		w.printf("loc_%s,", localeToCatalogSuffix(t))
+	}
+	w.println("}")
+
 	// Type definition.
 	catalogTypeName := TypePrefixCatalog + localeCatalogSuffix
 	w.printf("type %s struct {}\n", catalogTypeName)
 
-	// Translation functions map by TIK based on io.Writer.
-	writersMapName := "writers_" + localeCatalogSuffix
-	w.printf(
-		"var %s = map[string]func(w io.Writer, args ...any) (int, error) {\n",
-		writersMapName)
+	// Translation functions appending to a byte slice by TIK index (see tikIndex).
+	appendersName := "appenders_" + localeCatalogSuffix
+	w.printf("var %s = [tikCount]func(b []byte, args ...any) []byte {\n",
+		appendersName)
+	h := w.perfectHash()
+	written := make(map[int]bool)
 	for msg := range newMsgIter(w.scan, locale) {
-		w.writeFunc(msg.ID, msg.ICUMsg, msg.ICUTokens)
+		i := h.index(msg.TIK)
+		if i == -1 || written[i] {
+			continue // Not in the default catalog or a duplicate TIK.
+		}
+		written[i] = true
+		w.writeFunc(i, msg.ID, msg.ICUMsg, msg.ICUTokens)
 	}
 	w.println(`}`)
 
@@ -199,27 +281,40 @@ func (w *Writer) writeCatalogType(locale language.Tag) {
 	w.printf("func (%s) Translator() locales.Translator { return %s }\n\n",
 		catalogTypeName, w.translatorVar)
 
+	// Method Fallbacks.
+	w.printf("func (%s) Fallbacks() []language.Tag "+
+		"{ return slices.Clone(fallbacks_%s) }\n\n",
+		catalogTypeName, localeCatalogSuffix)
+
+	// Method Append.
+	w.printf("func (%s) Append(dst []byte, tik TIK, args ...any) []byte {\n",
+		catalogTypeName)
+	w.println("var f func(b []byte, args ...any) []byte;")
+	w.printf("if i := tikIndex(tik); i != -1 { f = %s[i] };\n", appendersName)
+	w.printf("if f == nil { return appendMissing(dst, %s, tik, args...) };\n",
+		localeVarName)
+	w.println("return f(dst, args...)")
+	w.print("}\n\n")
+
 	// Method String.
-	w.printf("func (%s) String(tik string, args ...any) string {\n",
+	w.printf("func (c %s) String(tik TIK, args ...any) string {\n",
 		catalogTypeName)
 	w.println(`b := poolBufGet()`)
 	w.println(`defer poolBufPut(b)`)
-	w.printf("f := %s[tik];\n", writersMapName)
-	w.printf(`if f == nil { _, _ = MissingTranslation(b, %s, tik, args...);`,
-		localeVarName)
-	w.println(`} else { _, _ = f(b, args...);`)
-	w.println(`}`)
-	w.println(`return b.String()`)
+	w.println(`return string(c.Append(b.AvailableBuffer(), tik, args...))`)
 	w.print("}\n\n")
 
 	// Method Write.
-	w.printf("func (%s) Write(\nwriter io.Writer, tik string, args ...any,\n)"+
+	w.printf("func (%s) Write(\nwriter io.Writer, tik TIK, args ...any,\n)"+
 		" (written int, err error) {\n",
 		catalogTypeName)
-	w.printf("f := %s[tik];\n", writersMapName)
-	w.printf(`if f == nil { return MissingTranslation(writer, %s, tik, args...) };`,
+	w.println("var f func(b []byte, args ...any) []byte;")
+	w.printf("if i := tikIndex(tik); i != -1 { f = %s[i] };\n", appendersName)
+	w.printf(`if f == nil { return MissingTranslation(writer, %s, string(tik), args...) };`,
 		localeVarName)
-	w.println("return f(writer, args...)")
+	w.println(`b := poolBufGet()`)
+	w.println(`defer poolBufPut(b)`)
+	w.println("return writer.Write(f(b.AvailableBuffer(), args...))")
 	w.print("}\n\n")
 }
 
@@ -0,0 +1,56 @@
+package gengo
+
+import (
+	_ "embed"
+	"io"
+	"strings"
+
+	tik "github.com/romshark/tik/tik-go"
+)
+
+//go:embed interpreter.go.txt
+var interpreterGoTxt string
+
+// WritePackageInterpreter writes the Go file of the bundle
+// providing LoadCatalog.
+func (w *Writer) WritePackageInterpreter(
+	writer io.Writer, packageName string, headTxtLines []string,
+) {
+	w.w, w.l = writer, w.scan.DefaultLocale
+
+	w.println("// Generated by github.com/romshark/toki. DO NOT EDIT.")
+	for _, l := range headTxtLines {
+		w.printf("// %s\n", l)
+	}
+	w.printf(interpreterGoTxt, packageName)
+
+	w.println("// catalogMessages holds all messages of the bundle by ID.")
+	w.println("var catalogMessages = map[string]catalogMessage{")
+	for msg := range newMsgIter(w.scan, w.scan.DefaultLocale) {
+		txt := w.scan.Texts.At(w.scan.TextIndexByID.GetValue(msg.ID))
+		w.printf("%q: {TIK: %s, Args: %q},\n", msg.ID, msg.ID, argKinds(txt.TIK))
+	}
+	w.println("}")
+}
+
+// argKinds returns the kinds of the placeholders of t
+// as expected by catalogMessage.Args in interpreter.go.txt.
+func argKinds(t tik.TIK) string {
+	var b strings.Builder
+	for _, p := range t.Placeholders() {
+		switch p.Type {
+		case tik.TokenTypeText, tik.TokenTypeTextWithGender:
+			b.WriteByte('t')
+		case tik.TokenTypeCurrency:
+			b.WriteByte('c')
+		case tik.TokenTypeDateFull, tik.TokenTypeDateLong,
+			tik.TokenTypeDateMedium, tik.TokenTypeDateShort,
+			tik.TokenTypeTimeFull, tik.TokenTypeTimeLong,
+			tik.TokenTypeTimeMedium, tik.TokenTypeTimeShort:
+			b.WriteByte('d')
+		default: // Integers, numbers and plurals.
+			b.WriteByte('n')
+		}
+	}
+	return b.String()
+}
@@ -7,25 +7,26 @@ import (
 	"github.com/romshark/icumsg"
 )
 
-// writeFunc writes a translation function as a map entry.
-func (w *Writer) writeFunc(id, icuMsg string, tokens []icumsg.Token) {
+// writeFunc writes a translation function appending to a byte slice
+// as an array entry.
+func (w *Writer) writeFunc(index int, id, icuMsg string, tokens []icumsg.Token) {
 	w.m = icuMsg
 	w.t = tokens
 	w.i = 0
 
+	w.printf("// %s\n", id)
 	if icuMsg == "" {
-		w.printf("%s: nil,\n", id)
+		w.printf("%d: nil,\n", index)
 		return
 	}
 
-	w.printf("%s: func(w io.Writer, args ...any) (written int, err error) {\n", id)
+	w.printf("%d: func(b []byte, args ...any) []byte {\n", index)
 	endIndex := len(w.t)
 	if s := w.literalConcat(endIndex); s != "" {
-		w.printf("return wrs(w, %q)\n", unescapeICULiteral(s))
+		w.printf("return append(b, %q...)\n", unescapeICULiteral(s))
 	} else {
-		w.println("var n int;")
 		w.writeExpr(endIndex)
-		w.println("return written, nil;")
+		w.println("return b;")
 	}
 	w.println("},")
 }
@@ -33,8 +34,7 @@ func (w *Writer) writeFunc(id, icuMsg string, tokens []icumsg.Token) {
 func (w *Writer) writeExpr(endIndex int) {
 	if s := w.literalConcat(endIndex); s != "" {
 		w.println("_ = args")
-		w.printf("n, err = wrs(w, %q);\n", s)
-		w.println("if err != nil {return written, err}; written += n;")
+		w.printf("b = append(b, %q...);\n", s)
 		return
 	}
 
@@ -42,8 +42,7 @@ func (w *Writer) writeExpr(endIndex int) {
 		t := w.t[w.i]
 		switch t.Type {
 		case icumsg.TokenTypeLiteral:
-			w.printf("n, err = wrs(w, %q)\n", unescapeICULiteral(t.String(w.m, w.t)))
-			w.println("if err != nil {return written, err}; written += n;")
+			w.printf("b = append(b, %q...)\n", unescapeICULiteral(t.String(w.m, w.t)))
 			w.i++ // Advance.
 		case icumsg.TokenTypeSimpleArg:
 			w.writeSimpleArg()
@@ -71,8 +70,7 @@ func (w *Writer) writeSimpleArg() {
 	}
 	if w.i+2 >= len(w.t) || !isTokenArgType(w.t[w.i+2].Type) {
 		// No argument type.
-		w.printf("{s, _ := sv(args[%d]); n, err = wrs(w, s)};\n", arg.Index)
-		w.println("if err != nil {return written, err}; written += n;")
+		w.printf("{s, _ := sv(args[%d]); b = append(b, s...)};\n", arg.Index)
 		w.i += 2
 		return
 	}
@@ -86,30 +84,21 @@ func (w *Writer) writeSimpleArg() {
 				case "::currency/auto":
 					w.println("{")
 					w.printf("c := args[%d].(Currency);\n", arg.Index)
-					w.printf("s := %s.FmtCurrency(c.Amount, 2, c.Type);\n",
+					w.printf("b = append(b, %s.FmtCurrency(c.Amount, 2, c.Type)...);\n",
 						w.translatorVar)
-					w.println("n, err = wrs(w, s);")
-					w.println("if err != nil {return written, err}; written += n;")
 					w.println("}")
 				default:
 					panic(fmt.Errorf("unsupported number skeleton: %q", s))
 				}
 			case icumsg.TokenTypeArgStyleInteger:
-				w.printf("switch a := args[%d].(type) {\n", arg.Index)
-				w.printf("case int,uint,int8,uint8,int16,uint16,int32,uint32,int64,uint64:"+
-					"n, err = fmt.Fprintf(w, \"%s\", a);\n", "%d")
-				w.printf("}\n")
-				w.println("if err != nil {return written, err}; written += n;")
+				w.printf("b = appendInt(b, args[%d]);\n", arg.Index)
 			default:
 				panic(fmt.Errorf("unsupported number style: %q", tp.String()))
 			}
 			w.i += 4
 			return
 		}
-		w.printf("switch a := args[%d].(type) {\n", arg.Index)
-		w.printf("case float32,float64: n, err = fmt.Fprintf(w, \"%s\", a);\n", "%g")
-		w.printf("}\n")
-		w.println("if err != nil {return written, err}; written += n;")
+		w.printf("b = appendFloat(b, args[%d]);\n", arg.Index)
 		w.i += 3
 		return
 	case icumsg.TokenTypeArgTypeDate:
@@ -124,8 +113,7 @@ func (w *Writer) writeSimpleArg() {
 	if !isTokenArgStyle(tokStyle.Type) {
 		// Argument type only.
 		w.i += 3
-		w.printf("n, err = wrs(w, args[%d].(string));\n", arg.Index)
-		w.println("if err != nil {return written, err}; written += n;")
+		w.printf("b = append(b, args[%d].(string)...);\n", arg.Index)
 		return
 	}
 	// This should never happen
@@ -137,22 +125,21 @@ func (w *Writer) writeArgDate(argIndex int) {
 	w.i += 4
 	switch tokStyle.Type {
 	case icumsg.TokenTypeArgStyleFull:
-		w.printf("n, err = io.WriteString(w, %s.FmtDateFull(args[%d].(time.Time)));",
+		w.printf("b = append(b, %s.FmtDateFull(args[%d].(time.Time))...);\n",
 			w.translatorVar, argIndex)
 	case icumsg.TokenTypeArgStyleLong:
-		w.printf("n, err = io.WriteString(w, %s.FmtDateLong(args[%d].(time.Time)));",
+		w.printf("b = append(b, %s.FmtDateLong(args[%d].(time.Time))...);\n",
 			w.translatorVar, argIndex)
 	case icumsg.TokenTypeArgStyleMedium:
-		w.printf("n, err = io.WriteString(w, %s.FmtDateMedium(args[%d].(time.Time)));",
+		w.printf("b = append(b, %s.FmtDateMedium(args[%d].(time.Time))...);\n",
 			w.translatorVar, argIndex)
 	case icumsg.TokenTypeArgStyleShort:
-		w.printf("n, err = io.WriteString(w, %s.FmtDateShort(args[%d].(time.Time)));",
+		w.printf("b = append(b, %s.FmtDateShort(args[%d].(time.Time))...);\n",
 			w.translatorVar, argIndex)
 	default:
 		// This should never happen because this switch is exhaustive.
 		panic(tokStyle.Type.String())
 	}
-	w.println("if err != nil {return written, err}; written += n;")
 }
 
 func (w *Writer) writeArgTime(argIndex int) {
@@ -160,22 +147,21 @@ func (w *Writer) writeArgTime(argIndex int) {
 	w.i += 4
 	switch tokStyle.Type {
 	case icumsg.TokenTypeArgStyleFull:
-		w.printf("n, err = io.WriteString(w, %s.FmtTimeFull(args[%d].(time.Time)));",
+		w.printf("b = append(b, %s.FmtTimeFull(args[%d].(time.Time))...);\n",
 			w.translatorVar, argIndex)
 	case icumsg.TokenTypeArgStyleLong:
-		w.printf("n, err = io.WriteString(w, %s.FmtTimeLong(args[%d].(time.Time)));",
+		w.printf("b = append(b, %s.FmtTimeLong(args[%d].(time.Time))...);\n",
 			w.translatorVar, argIndex)
 	case icumsg.TokenTypeArgStyleMedium:
-		w.printf("n, err = io.WriteString(w, %s.FmtTimeMedium(args[%d].(time.Time)));",
+		w.printf("b = append(b, %s.FmtTimeMedium(args[%d].(time.Time))...);\n",
 			w.translatorVar, argIndex)
 	case icumsg.TokenTypeArgStyleShort:
-		w.printf("n, err = io.WriteString(w, %s.FmtTimeShort(args[%d].(time.Time)));",
+		w.printf("b = append(b, %s.FmtTimeShort(args[%d].(time.Time))...);\n",
 			w.translatorVar, argIndex)
 	default:
 		// This should never happen because this switch is exhaustive.
 		panic(tokStyle.Type.String())
 	}
-	w.println("if err != nil {return written, err}; written += n;")
 }
 
 func (w *Writer) writePlural(ordinal bool) {
@@ -267,23 +253,15 @@ func (w *Writer) writePluralOption(arg argName, offset uint64) {
 			for s := range iterPluralLiteralParts(t.String(w.m, w.t)) {
 				if s == "#" {
 					if offset != 0 {
-						w.printf(
-							"n, err = fmt.Fprintf(w, %q, subtract(args[%d], %d));\n",
-							"%v", arg.Index, offset,
-						)
-						w.println("if err != nil {return written, err}; written += n;")
+						w.printf("b = appendNumber(b, subtract(args[%d], %d));\n",
+							arg.Index, offset)
 						continue
 					} else {
-						w.printf(
-							"n, err = fmt.Fprintf(w, %q, args[%d]);\n",
-							"%v", arg.Index,
-						)
-						w.println("if err != nil {return written, err}; written += n;")
+						w.printf("b = appendNumber(b, args[%d]);\n", arg.Index)
 						continue
 					}
 				}
-				w.printf("n, err = wrs(w, %q);\n", unescapeICULiteral(s))
-				w.println("if err != nil {return written, err}; written += n;")
+				w.printf("b = append(b, %q...);\n", unescapeICULiteral(s))
 			}
 			w.i++
 		case icumsg.TokenTypeSimpleArg:
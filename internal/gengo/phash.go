@@ -0,0 +1,172 @@
+package gengo
+
+import (
+	"math/bits"
+	"slices"
+)
+
+// perfectHash maps the TIKs of a bundle to distinct indexes without hashing
+// them in full: only their length and three 8-byte words at their start,
+// middle and end are hashed (see tikHash), which takes constant time.
+// A TIK is found at the slot of Table its hash displaced by the displacement
+// of its bucket points at, which is verified by comparing the TIK to the one
+// in the slot. TIKs with equal hashes, such as long TIKs that only differ
+// between the hashed words, are indexed by Fallback instead.
+//
+// The generated function tikIndex in template.go.txt must implement
+// the same algorithm as index. Table and Displacements have a length
+// that is a power of two, so their indexes are computed by masking.
+type perfectHash struct {
+	Displacements []uint32       // Displacement by bucket.
+	Table         []string       // TIKs by slot, empty for unused slots.
+	Fallback      map[string]int // Indexes of TIKs with colliding hashes.
+}
+
+// Len returns the number of indexes, which is the length of Table
+// plus the number of fallback TIKs.
+func (h perfectHash) Len() int { return len(h.Table) + len(h.Fallback) }
+
+// newPerfectHash builds the perfect hash of the distinct TIKs in tiks.
+// The result only depends on the set of TIKs, not their order.
+func newPerfectHash(tiks []string) perfectHash {
+	tiks = slices.Clone(tiks)
+	slices.Sort(tiks)
+	tiks = slices.Compact(tiks)
+
+	byHash := make(map[uint64][]string, len(tiks))
+	for _, t := range tiks {
+		h := tikHash(t)
+		byHash[h] = append(byHash[h], t)
+	}
+	var unique []string
+	var hashes []uint64
+	var fallback []string
+	for _, t := range tiks {
+		h := tikHash(t)
+		if len(byHash[h]) > 1 {
+			fallback = append(fallback, t)
+			continue
+		}
+		unique = append(unique, t)
+		hashes = append(hashes, h)
+	}
+
+	// Grow the table until all buckets can be placed.
+	size := 1 << bits.Len(uint(len(unique)+len(unique)/4))
+	for ; ; size *= 2 {
+		h, ok := placeBuckets(unique, hashes, size)
+		if !ok {
+			continue
+		}
+		if len(fallback) > 0 {
+			h.Fallback = make(map[string]int, len(fallback))
+			for i, t := range fallback {
+				h.Fallback[t] = len(h.Table) + i
+			}
+		}
+		return h
+	}
+}
+
+// maxDisplacement limits the displacements tried per bucket
+// before the table is grown.
+const maxDisplacement = 1 << 16
+
+// placeBuckets distributes the TIKs over a table of size slots
+// largest bucket first. Returns false if any bucket can't be placed.
+func placeBuckets(tiks []string, hashes []uint64, size int) (perfectHash, bool) {
+	h := perfectHash{
+		Displacements: make([]uint32, 1<<bits.Len(uint(len(tiks)/4))),
+		Table:         make([]string, size),
+	}
+	buckets := make([][]int, len(h.Displacements))
+	for i, hash := range hashes {
+		b := hash & uint64(len(buckets)-1)
+		buckets[b] = append(buckets[b], i)
+	}
+	order := make([]int, len(buckets))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return len(buckets[b]) - len(buckets[a])
+	})
+
+	used := make([]bool, size)
+	slots := make([]int, 0, 8)
+NEXT_BUCKET:
+	for _, b := range order {
+		if len(buckets[b]) == 0 {
+			break // All remaining buckets are empty.
+		}
+	NEXT_DISPLACEMENT:
+		for d := uint32(0); d < maxDisplacement; d++ {
+			slots = slots[:0]
+			for _, i := range buckets[b] {
+				s := int(mix(hashes[i]^uint64(d)) & uint64(size-1))
+				if used[s] || slices.Contains(slots, s) {
+					continue NEXT_DISPLACEMENT
+				}
+				slots = append(slots, s)
+			}
+			for j, i := range buckets[b] {
+				used[slots[j]] = true
+				h.Table[slots[j]] = tiks[i]
+			}
+			h.Displacements[b] = d
+			continue NEXT_BUCKET
+		}
+		return perfectHash{}, false
+	}
+	return h, true
+}
+
+// index returns the index of tik or -1 if tik isn't indexed.
+func (h perfectHash) index(tik string) int {
+	hash := tikHash(tik)
+	d := h.Displacements[hash&uint64(len(h.Displacements)-1)]
+	i := int(mix(hash^uint64(d)) & uint64(len(h.Table)-1))
+	if h.Table[i] == tik {
+		return i
+	}
+	if i, ok := h.Fallback[tik]; ok {
+		return i
+	}
+	return -1
+}
+
+// tikHash hashes the length of tik and the 8-byte words at its start,
+// middle and end, which cover TIKs of up to 24 bytes entirely.
+func tikHash(tik string) uint64 {
+	const k = 0x9e3779b97f4a7c15
+	n := len(tik)
+	h := uint64(n) * k
+	if n < 8 {
+		var w uint64
+		for i := range n {
+			w |= uint64(tik[i]) << (8 * i)
+		}
+		return mix(h ^ w)
+	}
+	h = (h ^ word(tik, 0)) * k
+	h = (h ^ word(tik, n/2-4)) * k
+	h = (h ^ word(tik, n-8)) * k
+	return mix(h)
+}
+
+// word returns the little-endian 8-byte word of s at offset i.
+func word(s string, i int) uint64 {
+	return uint64(s[i]) | uint64(s[i+1])<<8 | uint64(s[i+2])<<16 |
+		uint64(s[i+3])<<24 | uint64(s[i+4])<<32 | uint64(s[i+5])<<40 |
+		uint64(s[i+6])<<48 | uint64(s[i+7])<<56
+}
+
+// mix is the finalizer of MurmurHash3 spreading the bits of h.
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
@@ -0,0 +1,103 @@
+package gengo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const lorem = "Lorem ipsum dolor sit amet, consectetur adipiscing elit, " +
+	"sed do eiusmod tempor incididunt ut labore et dolore magna aliqua. " +
+	"Ut enim ad minim veniam, quis nostrud exercitation ullamco laboris " +
+	"nisi ut aliquip ex ea commodo consequat. "
+
+// testTIKs returns n short and n long TIKs. The long ones share their
+// prefix and suffix and only differ in the middle.
+func testTIKs(n int) []string {
+	tiks := make([]string, 0, 2*n)
+	for i := range n {
+		tiks = append(tiks, fmt.Sprintf("Save %d", i))
+		long := strings.Repeat(lorem, 4) + fmt.Sprint(i) + strings.Repeat(lorem, 4)
+		tiks = append(tiks, long)
+	}
+	return tiks
+}
+
+func TestPerfectHash(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 10, 1000} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			tiks := testTIKs(n)
+			h := newPerfectHash(tiks)
+			require.NotEmpty(t, h.Table)
+
+			seen := make(map[int]bool, len(tiks))
+			for _, tik := range tiks {
+				i := h.index(tik)
+				require.NotEqual(t, -1, i, "TIK %q not found", tik)
+				require.False(t, seen[i], "TIK %q at occupied slot %d", tik, i)
+				require.Equal(t, tik, h.Table[i])
+				seen[i] = true
+			}
+			require.Equal(t, -1, h.index("Unknown"))
+			require.Equal(t, -1, h.index(strings.Repeat(lorem, 8)))
+
+			// The result doesn't depend on the order of the TIKs.
+			reversed := slices.Clone(tiks)
+			slices.Reverse(reversed)
+			require.Equal(t, h, newPerfectHash(reversed))
+		})
+	}
+}
+
+func TestPerfectHashFallback(t *testing.T) {
+	// Equal in length and in the hashed words at the start, middle and end.
+	a := "Hello " + lorem + "Bob! " + strings.Repeat(lorem, 3) + "Bye"
+	b := "Hello " + lorem + "Tim! " + strings.Repeat(lorem, 3) + "Bye"
+	h := newPerfectHash([]string{a, b, "Save"})
+	require.Equal(t, map[string]int{a: len(h.Table), b: len(h.Table) + 1}, h.Fallback)
+	require.Equal(t, len(h.Table)+2, h.Len())
+	require.Equal(t, len(h.Table), h.index(a))
+	require.Equal(t, len(h.Table)+1, h.index(b))
+	require.Equal(t, "Save", h.Table[h.index("Save")])
+	require.Equal(t, -1, h.index("Hello "+strings.Repeat(lorem, 4)))
+}
+
+func TestPerfectHashDuplicates(t *testing.T) {
+	h := newPerfectHash([]string{"a", "b", "a"})
+	require.Equal(t, h.Table[h.index("a")], "a")
+	require.Equal(t, h.Table[h.index("b")], "b")
+}
+
+// BenchmarkDispatch compares the perfect hash index of the generated catalogs
+// with the map lookup by TIK it replaced.
+func BenchmarkDispatch(b *testing.B) {
+	tiks := testTIKs(500)
+	h := newPerfectHash(tiks)
+	m := make(map[string]int, len(tiks))
+	for i, tik := range tiks {
+		m[tik] = i
+	}
+	for _, kind := range []string{"short", "long"} {
+		tik := tiks[len(tiks)/2] // Short TIKs are at even indexes.
+		if kind == "long" {
+			tik = tiks[len(tiks)/2+1]
+		}
+		b.Run(kind+"/perfect_hash", func(b *testing.B) {
+			for b.Loop() {
+				if h.index(tik) == -1 {
+					b.Fatal("not found")
+				}
+			}
+		})
+		b.Run(kind+"/map", func(b *testing.B) {
+			for b.Loop() {
+				if _, ok := m[tik]; !ok {
+					b.Fatal("not found")
+				}
+			}
+		})
+	}
+}
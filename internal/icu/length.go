@@ -0,0 +1,130 @@
+package icu
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/romshark/icumsg"
+	"golang.org/x/text/width"
+)
+
+// Chars returns the number of characters (runes) in s.
+func Chars(s string) int { return utf8.RuneCountInString(s) }
+
+// Width returns the display width of s in monospace cells.
+// East Asian wide and fullwidth characters take two cells,
+// combining marks and format characters take none.
+func Width(s string) (w int) {
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		case width.LookupRune(r).Kind() == width.EastAsianWide,
+			width.LookupRune(r).Kind() == width.EastAsianFullwidth:
+			w += 2
+		default:
+			w++
+		}
+	}
+	return w
+}
+
+// FnArg returns the worst-case value of the argument name.
+// argType is one of the icumsg.TokenTypeArgType* types or 0 for plain arguments
+// and argStyle is one of the icumsg.TokenTypeArgStyle* types or 0 if none.
+// Occurrences of # in plural options have argType icumsg.TokenTypeArgTypeNumber
+// and argStyle icumsg.TokenTypeArgStyleInteger.
+type FnArg func(name string, argType, argStyle icumsg.TokenType) string
+
+// MaxLength returns the worst-case length of the ICU message raw as measured
+// by measure. Every plural and select option is expanded and the longest is
+// taken while arguments are substituted by their worst-case values.
+func MaxLength(
+	raw string, tokens []icumsg.Token, measure func(string) int, arg FnArg,
+) int {
+	return maxLength(raw, tokens, 0, len(tokens), "", measure, arg)
+}
+
+// maxLength returns the worst-case length of tokens[i:end].
+// pluralArg is the name of the argument # refers to or "" if none.
+func maxLength(
+	raw string, tokens []icumsg.Token, i, end int, pluralArg string,
+	measure func(string) int, arg FnArg,
+) (n int) {
+	for i < end {
+		t := tokens[i]
+		switch t.Type {
+		case icumsg.TokenTypeLiteral:
+			s := unescapeLiteral(t.String(raw, tokens))
+			if pluralArg != "" {
+				s = strings.ReplaceAll(s, "#", arg(pluralArg,
+					icumsg.TokenTypeArgTypeNumber, icumsg.TokenTypeArgStyleInteger))
+			}
+			n += measure(s)
+			i++
+		case icumsg.TokenTypeSimpleArg:
+			name := tokens[i+1].String(raw, tokens)
+			i += 2
+			var argType, argStyle icumsg.TokenType
+			if i < end && tokens[i].Type >= icumsg.TokenTypeArgTypeNumber &&
+				tokens[i].Type <= icumsg.TokenTypeArgTypeDuration {
+				argType = tokens[i].Type
+				i++
+				if i < end && tokens[i].Type >= icumsg.TokenTypeArgStyleShort &&
+					tokens[i].Type <= icumsg.TokenTypeArgStyleSkeleton {
+					argStyle = tokens[i].Type
+					i++
+				}
+			}
+			n += measure(arg(name, argType, argStyle))
+		case icumsg.TokenTypePlural,
+			icumsg.TokenTypeSelectOrdinal,
+			icumsg.TokenTypeSelect:
+			optionArg := pluralArg
+			if t.Type != icumsg.TokenTypeSelect {
+				optionArg = tokens[i+1].String(raw, tokens)
+			}
+			longest := 0
+			for o := i + 2; o < t.IndexEnd; {
+				if tokens[o].Type < icumsg.TokenTypeOption ||
+					tokens[o].Type > icumsg.TokenTypeOptionNumber {
+					o++ // Argument name, offset or option name.
+					continue
+				}
+				start := o + 1
+				if tokens[start].Type == icumsg.TokenTypeOptionName {
+					start++
+				}
+				longest = max(longest, maxLength(
+					raw, tokens, start, tokens[o].IndexEnd, optionArg, measure, arg,
+				))
+				o = tokens[o].IndexEnd + 1 // Skip to after the option terminator.
+			}
+			n += longest
+			i = t.IndexEnd + 1
+		default:
+			i++
+		}
+	}
+	return n
+}
+
+// unescapeLiteral removes ICU quoting from a literal.
+func unescapeLiteral(raw string) string {
+	if !strings.Contains(raw, "'") {
+		return raw
+	}
+	var b strings.Builder
+	b.Grow(len(raw))
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\'' {
+			b.WriteByte(raw[i])
+			continue
+		}
+		if i+1 < len(raw) && raw[i+1] == '\'' {
+			b.WriteByte('\'') // Escaped quote.
+			i++
+		}
+	}
+	return b.String()
+}
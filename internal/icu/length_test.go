@@ -0,0 +1,56 @@
+package icu_test
+
+import (
+	"testing"
+
+	"github.com/romshark/toki/internal/icu"
+
+	"github.com/romshark/icumsg"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestMaxLength(t *testing.T) {
+	tk := new(icumsg.Tokenizer)
+	arg := func(name string, argType, argStyle icumsg.TokenType) string {
+		switch {
+		case argType == icumsg.TokenTypeArgTypeNumber:
+			return "999"
+		case argType == icumsg.TokenTypeArgTypeDate &&
+			argStyle == icumsg.TokenTypeArgStyleShort:
+			return "9/30/26"
+		}
+		return "Alice"
+	}
+	f := func(t *testing.T, locale language.Tag, input string, expectChars int) {
+		t.Helper()
+		tokens, err := tk.Tokenize(locale, nil, input)
+		require.NoError(t, err)
+		require.Equal(t, expectChars, icu.MaxLength(input, tokens, icu.Chars, arg))
+	}
+
+	f(t, language.English, "", 0)
+	f(t, language.English, "Save", 4)
+	f(t, language.English, "It''s done", 9)
+	f(t, language.English, "Hi {var0}!", 9)
+	f(t, language.English, "Due {var0, date, short}", 11)
+	f(t, language.English, "{var0, plural, one {# item} other {# items}}", 9)
+	f(t, language.English,
+		"{var0, plural, =0 {Your shopping cart is empty} other {# items}}", 27)
+	f(t, language.German, `{var0_gender, select,
+		female {Sie hat {var1, plural, one {# Nachricht} other {# Nachrichten}}}
+		other {{var0}: {var1, plural, one {# Nachricht} other {# Nachrichten}}}
+	}`, 23)
+	f(t, language.English,
+		"{var0, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}", 5)
+}
+
+func TestWidth(t *testing.T) {
+	require.Equal(t, 0, icu.Width(""))
+	require.Equal(t, 5, icu.Width("Hallo"))
+	require.Equal(t, 4, icu.Width("保存"))
+	require.Equal(t, 4, icu.Width("ＡＢ"))
+	require.Equal(t, 4, icu.Width("Café"))
+	require.Equal(t, 4, icu.Width("Café")) // Combining acute accent.
+	require.Equal(t, 5, icu.Chars("Café"))
+}
@@ -0,0 +1,51 @@
+// Package levenshtein computes the Levenshtein edit distance between strings.
+package levenshtein
+
+import "unicode/utf8"
+
+// Distance returns the minimum number of single rune insertions,
+// deletions and substitutions required to change a into b.
+func Distance(a, b string) int {
+	if a == b {
+		return 0
+	}
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < len(rb) {
+		ra, rb = rb, ra // Keep the row as short as possible.
+	}
+	if len(rb) == 0 {
+		return len(ra)
+	}
+
+	row := make([]int, len(rb)+1)
+	for i := range row {
+		row[i] = i
+	}
+	for i := 1; i <= len(ra); i++ {
+		prev := row[0] // Value of row[j-1] from the previous iteration.
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current := row[j]
+			row[j] = min(row[j]+1, row[j-1]+1, prev+cost)
+			prev = current
+		}
+	}
+	return row[len(rb)]
+}
+
+// Similar returns true if the distance between a and b doesn't exceed
+// the given ratio of the longer string's length in runes.
+// Strings with a distance of 1 are always considered similar unless
+// either of them is a single rune.
+func Similar(a, b string, ratio float64) bool {
+	l := max(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+	if l < 2 {
+		return a == b
+	}
+	limit := max(int(float64(l)*ratio), 1)
+	return Distance(a, b) <= limit
+}
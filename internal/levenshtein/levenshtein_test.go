@@ -0,0 +1,37 @@
+package levenshtein_test
+
+import (
+	"testing"
+
+	"github.com/romshark/toki/internal/levenshtein"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistance(t *testing.T) {
+	f := func(t *testing.T, a, b string, expect int) {
+		t.Helper()
+		require.Equal(t, expect, levenshtein.Distance(a, b))
+		require.Equal(t, expect, levenshtein.Distance(b, a))
+	}
+
+	f(t, "", "", 0)
+	f(t, "abc", "", 3)
+	f(t, "abc", "abc", 0)
+	f(t, "kitten", "sitting", 3)
+	f(t, "Save changes", "Save Changes.", 2)
+	f(t, "Größe", "Grosse", 3)
+}
+
+func TestSimilar(t *testing.T) {
+	f := func(t *testing.T, a, b string, ratio float64, expect bool) {
+		t.Helper()
+		require.Equal(t, expect, levenshtein.Similar(a, b, ratio))
+	}
+
+	f(t, "a", "b", .25, false)
+	f(t, "Yes", "No", .25, false)
+	f(t, "Sve", "Save", .25, true)
+	f(t, "Recieve messages", "Receive messages", .25, true)
+	f(t, "Delete account", "Create project", .25, false)
+}
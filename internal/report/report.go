@@ -0,0 +1,300 @@
+// Package report writes lint findings in formats understood by code scanning
+// tools: SARIF, GitHub Actions workflow commands and Checkstyle XML.
+package report
+
+import (
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Rule is a kind of finding. Rule IDs are stable and must never change.
+type Rule struct {
+	ID          string
+	Description string
+}
+
+var (
+	RuleSourceError = Rule{
+		ID:          "toki/source-error",
+		Description: "Invalid TIK or invalid call to a bundle Reader method.",
+	}
+	RuleTIKCollision = Rule{
+		ID:          "toki/tik-collision",
+		Description: "Different TIKs produce the same message ID.",
+	}
+	RuleARBInvalid = Rule{
+		ID:          "toki/arb-invalid",
+		Description: "The .arb catalog file can't be decoded.",
+	}
+	RuleICUUnsupportedSelectOption = Rule{
+		ID:          "toki/icu-unsupported-select-option",
+		Description: "The ICU message uses a select option that isn't supported.",
+	}
+	RuleICUIncomplete = Rule{
+		ID:          "toki/icu-incomplete",
+		Description: "The ICU message is incomplete, for example it's missing plural options.",
+	}
+	RuleMaxLength = Rule{
+		ID:          "toki/max-length",
+		Description: "The message may render longer than its declared maximum length.",
+	}
+	RuleNearDuplicate = Rule{
+		ID:          "toki/near-duplicate",
+		Description: "The TIK likely means the same as another TIK and should be merged.",
+	}
+	RuleBuildPartial = Rule{
+		ID:          "toki/build-partial",
+		Description: "The TIK is found in only some of the scanned build configurations.",
+	}
+	RuleStaleFile = Rule{
+		ID:          "toki/stale-file",
+		Description: "The generated file is out of date, rerun `toki generate`.",
+	}
+)
+
+// Rules lists all rules in a stable order.
+var Rules = []Rule{
+	RuleSourceError,
+	RuleTIKCollision,
+	RuleARBInvalid,
+	RuleICUUnsupportedSelectOption,
+	RuleICUIncomplete,
+	RuleMaxLength,
+	RuleNearDuplicate,
+	RuleBuildPartial,
+	RuleStaleFile,
+}
+
+// Finding is a single problem found at a location.
+// File is a slash separated path relative to the repository root.
+// Line and Col are 1-based and 0 if unknown.
+type Finding struct {
+	Rule     Rule
+	Severity Severity
+	Message  string
+	File     string
+	Line     int
+	Col      int
+}
+
+// Sort sorts findings by location, rule and message.
+func Sort(findings []Finding) {
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		return cmp.Or(
+			cmp.Compare(a.File, b.File),
+			cmp.Compare(a.Line, b.Line),
+			cmp.Compare(a.Col, b.Col),
+			cmp.Compare(a.Rule.ID, b.Rule.ID),
+			cmp.Compare(a.Message, b.Message),
+		)
+	})
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     Severity        `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// WriteSARIF writes findings as a SARIF 2.1.0 log.
+func WriteSARIF(w io.Writer, toolVersion string, findings []Finding) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "toki",
+			Version:        toolVersion,
+			InformationURI: "https://github.com/romshark/toki",
+			Rules:          make([]sarifRule, len(Rules)),
+		}},
+		Results: make([]sarifResult, len(findings)),
+	}
+	for i, r := range Rules {
+		run.Tool.Driver.Rules[i] = sarifRule{
+			ID: r.ID, ShortDescription: sarifMessage{Text: r.Description},
+		}
+	}
+	for i, f := range findings {
+		res := sarifResult{
+			RuleID:    f.Rule.ID,
+			RuleIndex: slices.IndexFunc(Rules, func(r Rule) bool { return r.ID == f.Rule.ID }),
+			Level:     f.Severity,
+			Message:   sarifMessage{Text: f.Message},
+		}
+		if f.File != "" {
+			loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(f.File)},
+			}}
+			if f.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{
+					StartLine: f.Line, StartColumn: f.Col,
+				}
+			}
+			res.Locations = []sarifLocation{loc}
+		}
+		run.Results[i] = res
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// WriteGitHub writes findings as GitHub Actions workflow commands
+// which show up as annotations on pull requests.
+func WriteGitHub(w io.Writer, findings []Finding) error {
+	var b strings.Builder
+	for _, f := range findings {
+		b.WriteString("::")
+		b.WriteString(string(f.Severity))
+		sep := " "
+		property := func(name, value string) {
+			b.WriteString(sep + name + "=" + escapeGitHubProperty(value))
+			sep = ","
+		}
+		if f.File != "" {
+			property("file", filepath.ToSlash(f.File))
+			if f.Line > 0 {
+				property("line", fmt.Sprint(f.Line))
+			}
+			if f.Col > 0 {
+				property("col", fmt.Sprint(f.Col))
+			}
+		}
+		property("title", f.Rule.ID)
+		b.WriteString("::")
+		b.WriteString(escapeGitHubData(f.Message))
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var (
+	gitHubDataEscaper = strings.NewReplacer(
+		"%", "%25", "\r", "%0D", "\n", "%0A",
+	)
+	gitHubPropertyEscaper = strings.NewReplacer(
+		"%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C",
+	)
+)
+
+func escapeGitHubData(s string) string     { return gitHubDataEscaper.Replace(s) }
+func escapeGitHubProperty(s string) string { return gitHubPropertyEscaper.Replace(s) }
+
+type checkstyle struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int      `xml:"line,attr,omitempty"`
+	Column   int      `xml:"column,attr,omitempty"`
+	Severity Severity `xml:"severity,attr"`
+	Message  string   `xml:"message,attr"`
+	Source   string   `xml:"source,attr"`
+}
+
+// WriteCheckstyle writes findings as Checkstyle XML grouped by file
+// in order of first appearance.
+func WriteCheckstyle(w io.Writer, findings []Finding) error {
+	doc := checkstyle{Version: "4.3"}
+	for _, f := range findings {
+		name := filepath.ToSlash(f.File)
+		i := slices.IndexFunc(doc.Files, func(c checkstyleFile) bool {
+			return c.Name == name
+		})
+		if i == -1 {
+			i = len(doc.Files)
+			doc.Files = append(doc.Files, checkstyleFile{Name: name})
+		}
+		doc.Files[i].Errors = append(doc.Files[i].Errors, checkstyleError{
+			Line:     f.Line,
+			Column:   f.Col,
+			Severity: f.Severity,
+			Message:  f.Message,
+			Source:   f.Rule.ID,
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,114 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/romshark/toki/internal/report"
+
+	"github.com/stretchr/testify/require"
+)
+
+var findings = []report.Finding{
+	{
+		Rule:     report.RuleTIKCollision,
+		Severity: report.SeverityError,
+		Message:  `TIK collision: "a" collides with "b"`,
+		File:     "pkg/main.go",
+		Line:     3,
+		Col:      7,
+	},
+	{
+		Rule:     report.RuleICUIncomplete,
+		Severity: report.SeverityWarning,
+		Message:  "Argument \"n\" is missing options [one,\nother]",
+		File:     "tokibundle/catalog_de.arb",
+	},
+}
+
+func TestWriteGitHub(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, report.WriteGitHub(&b, findings))
+	require.Equal(t,
+		"::error file=pkg/main.go,line=3,col=7,title=toki/tik-collision"+
+			`::TIK collision: "a" collides with "b"`+"\n"+
+			"::warning file=tokibundle/catalog_de.arb,title=toki/icu-incomplete"+
+			`::Argument "n" is missing options [one,%0Aother]`+"\n",
+		b.String())
+}
+
+func TestWriteCheckstyle(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, report.WriteCheckstyle(&b, findings))
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="4.3">
+  <file name="pkg/main.go">
+    <error line="3" column="7" severity="error" `+
+		`message="TIK collision: &#34;a&#34; collides with &#34;b&#34;" `+
+		`source="toki/tik-collision"></error>
+  </file>
+  <file name="tokibundle/catalog_de.arb">
+    <error severity="warning" `+
+		`message="Argument &#34;n&#34; is missing options [one,&#xA;other]" `+
+		`source="toki/icu-incomplete"></error>
+  </file>
+</checkstyle>
+`, b.String())
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, report.WriteSARIF(&b, "v1.2.3", findings))
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name    string `json:"name"`
+					Version string `json:"version"`
+					Rules   []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region *struct {
+							StartLine   int `json:"startLine"`
+							StartColumn int `json:"startColumn"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(b.Bytes(), &log))
+	require.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	require.Equal(t, "toki", run.Tool.Driver.Name)
+	require.Equal(t, "v1.2.3", run.Tool.Driver.Version)
+	require.Len(t, run.Tool.Driver.Rules, len(report.Rules))
+
+	require.Len(t, run.Results, 2)
+	for i, r := range run.Results {
+		require.Equal(t, findings[i].Rule.ID, r.RuleID)
+		require.Equal(t, findings[i].Rule.ID, run.Tool.Driver.Rules[r.RuleIndex].ID)
+		require.Equal(t, string(findings[i].Severity), r.Level)
+		require.Equal(t, findings[i].File, r.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	}
+	region := run.Results[0].Locations[0].PhysicalLocation.Region
+	require.NotNil(t, region)
+	require.Equal(t, 3, region.StartLine)
+	require.Equal(t, 7, region.StartColumn)
+	require.Nil(t, run.Results[1].Locations[0].PhysicalLocation.Region)
+}
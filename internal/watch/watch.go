@@ -0,0 +1,222 @@
+// Package watch detects file changes by periodically polling the file system.
+package watch
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// FnMatch reports whether the file at path is of interest.
+type FnMatch func(path string) bool
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	hash    uint64
+}
+
+// Watcher polls all files matched by Match in the directory tree of Root.
+// All paths are absolute.
+// Directories ignored by the go tool (starting with '.' or '_' and testdata)
+// are skipped.
+type Watcher struct {
+	Root     string
+	Match    FnMatch
+	Interval time.Duration // Polling interval.
+	Debounce time.Duration // Time without changes before Wait returns.
+
+	files map[string]fileState
+}
+
+// New creates a new watcher. Call Snapshot before the first Wait.
+func New(
+	root string, match FnMatch, interval, debounce time.Duration,
+) (*Watcher, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{
+		Root:     root,
+		Match:    match,
+		Interval: interval,
+		Debounce: debounce,
+		files:    make(map[string]fileState),
+	}, nil
+}
+
+// Snapshot records the current state of all watched files.
+func (w *Watcher) Snapshot() error {
+	files, err := w.scan(w.Root, true)
+	if err != nil {
+		return err
+	}
+	w.files = files
+	return nil
+}
+
+// Accept accepts a write of contents hashing to hash (see Hash) to the file
+// at path made by yourself. A later change made by anyone else,
+// which is detected by comparing contents, isn't accepted and is reported
+// by the next Wait.
+func (w *Watcher) Accept(path string, hash uint64) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if !w.Match(path) {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil // Removed by anyone else.
+	}
+	if err != nil {
+		return err
+	}
+	s := fileState{modTime: info.ModTime(), size: info.Size()}
+	if err := s.computeHash(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if s.hash == hash {
+		w.files[path] = s
+	}
+	return nil
+}
+
+// AcceptRemove accepts the removal of the file at path made by yourself
+// unless it was recreated by anyone else in the meantime.
+func (w *Watcher) AcceptRemove(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	switch _, err := os.Stat(path); {
+	case errors.Is(err, fs.ErrNotExist):
+		delete(w.files, path)
+	case err != nil:
+		return err
+	}
+	return nil
+}
+
+// Hash returns the hash of file contents data as used by Accept.
+func Hash(data []byte) uint64 { return xxhash.Sum64(data) }
+
+// Wait blocks until any of the watched files was created, changed or removed
+// and no further changes were detected for the debounce duration.
+// Returns the sorted paths of all changed files and records their new state.
+func (w *Watcher) Wait(ctx context.Context) (changed []string, err error) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	var lastChange time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		files, err := w.scan(w.Root, false)
+		if err != nil {
+			return nil, err
+		}
+		if c := w.diff(files); len(c) > 0 {
+			changed = append(changed, c...)
+			w.files = files
+			lastChange = time.Now()
+			continue
+		}
+		if len(changed) > 0 && time.Since(lastChange) >= w.Debounce {
+			slices.Sort(changed)
+			return slices.Compact(changed), nil
+		}
+	}
+}
+
+// diff returns the paths of all files that differ from the current snapshot.
+// The hash of unchanged files is carried over from the snapshot.
+func (w *Watcher) diff(files map[string]fileState) (changed []string) {
+	for path, s := range files {
+		prev, ok := w.files[path]
+		if ok && prev.modTime.Equal(s.modTime) && prev.size == s.size {
+			s.hash = prev.hash
+			files[path] = s
+			continue
+		}
+		// Compare contents since modification time alone isn't reliable.
+		err := s.computeHash(path)
+		files[path] = s
+		if !ok || err != nil || s.hash != prev.hash {
+			changed = append(changed, path)
+		}
+	}
+	for path := range w.files {
+		if _, ok := files[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
+
+func (s *fileState) computeHash(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	s.hash = Hash(data)
+	return nil
+}
+
+// scan returns the state of all matched files in dir.
+// Hashes are only computed if withHashes is true.
+func (w *Watcher) scan(dir string, withHashes bool) (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			return nil // Skip inaccessible and vanished files.
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (strings.HasPrefix(name, ".") ||
+				strings.HasPrefix(name, "_") || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !w.Match(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		s := fileState{modTime: info.ModTime(), size: info.Size()}
+		if withHashes {
+			if err := s.computeHash(path); err != nil {
+				return nil // Vanished in the meantime.
+			}
+		}
+		files[path] = s
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
@@ -0,0 +1,100 @@
+package watch_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romshark/toki/internal/watch"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	write := func(t *testing.T, name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+	pathA := write(t, "a.go", "package a")
+	write(t, "notes.txt", "ignored")
+
+	w, err := watch.New(dir, func(path string) bool {
+		return strings.HasSuffix(path, ".go")
+	}, 5*time.Millisecond, 100*time.Millisecond)
+	require.NoError(t, err)
+	require.NoError(t, w.Snapshot())
+
+	wait := func(t *testing.T, timeout time.Duration) ([]string, error) {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return w.Wait(ctx)
+	}
+
+	t.Run("change", func(t *testing.T) {
+		write(t, "a.go", "package a // changed")
+		changed, err := wait(t, 5*time.Second)
+		require.NoError(t, err)
+		require.Equal(t, []string{pathA}, changed)
+	})
+
+	t.Run("create and remove", func(t *testing.T) {
+		pathB := write(t, "sub/b.go", "package b")
+		require.NoError(t, os.Remove(pathA))
+		changed, err := wait(t, 5*time.Second)
+		require.NoError(t, err)
+		require.Equal(t, []string{pathA, pathB}, changed)
+	})
+
+	t.Run("ignored", func(t *testing.T) {
+		write(t, "notes.txt", "changed")
+		write(t, ".hidden/c.go", "package c")
+		write(t, "testdata/d.go", "package d")
+		write(t, "sub/b.go", "package b") // Same contents.
+		_, err := wait(t, 300*time.Millisecond)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("accept", func(t *testing.T) {
+		const content = "package b // changed"
+		pathB := write(t, "sub/b.go", content)
+		require.NoError(t, w.Accept(pathB, watch.Hash([]byte(content))))
+		pathE := write(t, "sub/e.go", "package e")
+		require.NoError(t, w.Accept(pathE, watch.Hash([]byte("package e"))))
+		_, err := wait(t, 300*time.Millisecond)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		require.NoError(t, os.Remove(pathE))
+		require.NoError(t, w.AcceptRemove(pathE))
+		_, err = wait(t, 300*time.Millisecond)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("accept changed by others", func(t *testing.T) {
+		// The contents written by yourself were changed by others in the meantime.
+		pathB := write(t, "sub/b.go", "package b // edited")
+		require.NoError(t, w.Accept(pathB, watch.Hash([]byte("package b // generated"))))
+		changed, err := wait(t, 5*time.Second)
+		require.NoError(t, err)
+		require.Equal(t, []string{pathB}, changed)
+	})
+
+	t.Run("debounce", func(t *testing.T) {
+		pathC := write(t, "c.go", "package c")
+		pathD := filepath.Join(dir, "d.go")
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			_ = os.WriteFile(pathD, []byte("package d"), 0o644)
+		}()
+		changed, err := wait(t, 5*time.Second)
+		require.NoError(t, err)
+		require.Equal(t, []string{pathC, pathD}, changed)
+	})
+}
@@ -30,6 +30,10 @@ type ICUMessage struct {
 
 	Catalog *Catalog
 
+	// PreviousTIK is set when the message was carried over from a similar
+	// previous TIK and needs to be reviewed.
+	PreviousTIK string
+
 	// Changed is true when there was a change to this message.
 	// In that case MessageOriginal holds the original message value.
 	Changed bool
@@ -41,6 +45,7 @@ type TIK struct {
 	ID          string
 	TIK         string
 	Description string
+	Locations   []string // Source positions (file:line) of all call sites.
 	ICU         []*ICUMessage
 }
 
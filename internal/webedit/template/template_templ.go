@@ -34,7 +34,7 @@ func htmlMain() templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!doctype html><html><head><title>Toki</title><meta name=\"viewport\" content=\"width=device-width, initial-scale=1\"><meta charset=\"UTF-8\"><meta name=\"description\" content=\"Toki web GUI for editing catalogs\"><script src=\"/static/htmx_min.js\"></script><script src=\"/static/app.js\"></script><script type=\"module\" src=\"/static/mode_icu.js\"></script><script src=\"https://cdnjs.cloudflare.com/ajax/libs/codemirror/5.65.16/codemirror.min.js\"></script><link rel=\"stylesheet\" href=\"https://cdnjs.cloudflare.com/ajax/libs/codemirror/5.65.18/codemirror.min.css\"><link rel=\"stylesheet\" href=\"https://cdnjs.cloudflare.com/ajax/libs/codemirror/5.65.18/theme/base16-light.min.css\" media=\"(prefers-color-scheme: light)\"><link rel=\"stylesheet\" href=\"https://cdnjs.cloudflare.com/ajax/libs/codemirror/5.65.18/theme/base16-dark.min.css\" media=\"(prefers-color-scheme: dark)\"><style>\n\t\thtml {\n\t\t\theight: 100%;\n\t\t}\n\n\t\tbody {\n\t\t\tmargin: 0;\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: row;\n\t\t\theight: 100%;\n\t\t\tfont-family: sans-serif;\n\t\t}\n\n\t\thr {\n\t\t\twidth: 100%;\n\t\t\tborder: 0;\n\t\t\tborder-bottom: 1px solid rgba(0,0,0,.1)\n\t\t}\n\n\t\tbutton, input[type=\"submit\"], textarea, .message-changed,\n\t\t\t.message-empty, .message-incomplete, .message-error {\n\t\t\tborder-radius: .2rem;\n\t\t}\n\n\t\t.selected {\n\t\t\tfont-weight: bold;\n\t\t\tcolor: black;\n\t\t}\n\n\t\tinput,\n\t\ttextarea,\n\t\tbutton {\n\t\t\tborder: none;\n\t\t\tpadding: .3rem;\n\t\t}\n\n\t\t.tik {\n\t\t\tfont-size: 1.4rem;\n\t\t\tline-height: 1.8rem;\n\t\t}\n\n\t\t#sidebar {\n\t\t\tmin-width: 8rem;\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tpadding: 1rem;\n\t\t\tgap: 1rem;\n\t\t\toverflow: auto;\n\t\t}\n\n\t\t#sidebar a {\n\t\t\ttext-decoration: none;\n\t\t}\n\n\t\t#sidebar > div {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tgap: .2rem;\n\t\t}\n\n\t\t#sidebar h1 {\n\t\t\tfont-size: 1.2rem;\n\t\t}\n\n\t\t#sidebar h2 {\n\t\t\tfont-size: 1rem;\n\t\t}\n\n\t\t#sidebar label input {\n\t\t\tmargin: 0;\n\t\t}\n\n\t\t#sidebar label {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: row;\n\t\t\theight: 1.5rem;\n\t\t\talign-items: center;\n\t\t}\n\n\t\t#sidebar label span {\n\t\t\tmargin-left: .5rem;\n\t\t}\n\n\t\t#sidebar .apply-changes {\n\t\t\tbackground-color: lightgreen;\n\t\t}\n\n\t\tmain {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tflex-grow: 1;\n\t\t\toverflow: auto;\n\t\t\tpadding: 1rem;\n\t\t\tpadding-left: 0;\n\t\t}\n\n\t\tmain .contents {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tgap: 1rem;\n\t\t\theight: fit-content;\n\t\t}\n\n\t\tmain .contents .no-results {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tjustify-content: center;\n\t\t\talign-items: center;\n\t\t\tmin-height: 10rem;\n\t\t}\n\n\t\tform {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tgap: .5rem;\n\t\t}\n\n\t\tlabel {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tflex: 1;\n\t\t}\n\n\t\tlabel>span {\n\t\t\tfont-weight: bold;\n\t\t\tfont-size: .8rem;\n\t\t}\n\n\t\tsection label>span {\n\t\t\tmargin-bottom: .5rem;\n\t\t}\n\n\t\tlabel .msg-id {\n\t\t\tdisplay: inline;\n\t\t\tmargin-left: .5rem;\n\t\t\tcolor: grey;\n\t\t}\n\n\t\tlabel p {\n\t\t\tmargin: 0;\n\t\t}\n\n\t\t.error {\n\t\t\tbackground: rgba(255, 0, 0, .3);\n\t\t\tcolor: black;\n\t\t\tpadding: .5rem;\n\t\t\tborder-radius: .2rem;\n\t\t\twidth: fit-content;\n\t\t\tmargin-top: .25rem;\n\t\t}\n\n\t\tmain section {\n\t\t\tmax-width: 100%;\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tborder: 1px solid rgba(0, 0, 0, .3);\n\t\t\tborder-radius: .2rem;\n\t\t}\n\n\t\tmain section>header {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tpadding: 1rem;\n\t\t\tgap: 1rem;\n\t\t\tbackground-color: rgba(0,0,0,0.03);\n\t\t}\n\n\t\tmain section ol {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: row;\n\t\t\tflex-wrap: nowrap;\n\t\t\toverflow-x: auto;\n\t\t\tgap: 1rem;\n\t\t\twidth: 100%;\n\t\t\tlist-style: none;\n\t\t\tmargin: 0;\n\t\t\tpadding: 1rem;\n\t\t\tbox-sizing: border-box;\n\t\t}\n\n\t\t.CodeMirror {\n\t\t\theight: auto;\n\t\t}\n\n\t\tmain section .icu-message {\n\t\t\tflex: 1 1;\n\t\t\tbox-sizing: border-box;\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\twidth: 100%;\n\t\t}\n\n\t\tmain section .icu-message textarea,\n\t\tmain section .icu-message .CodeMirror {\n\t\t\twidth: 100%;\n\t\t\tbox-sizing: border-box;\n\t\t\tmax-height: 90vh;\n\t\t\tmin-width: 14rem;\n\t\t\tmax-width: 100%;\n\t\t\tfont-size: 1rem;\n\t\t\toverflow: hidden;\n\t\t}\n\n\t\t.message-incomplete, .message-empty {\n\t\t\tpadding: .5rem;\n\t\t\tbackground-color: beige;\n\t\t}\n\n\t\t.message-changed {\n\t\t\tpadding: .5rem;\n\t\t\tbackground-color: lightblue;\n\t\t}\n\n\t\t.message-error {\n\t\t\tpadding: .5rem;\n\t\t\tbackground-color: #ffc1c1;\n\t\t}\n\n\t\t.message-changed .no-translation {\n\t\t\tfont-style: italic;\n\t\t\topacity: 0.5;\n\t\t}\n\n\t\t.message-incomplete ul {\n\t\t\tpadding: 0;\n\t\t\tpadding-left: 1rem;\n\t\t\tbox-sizing: border-box;\n\t\t}\n\n\t\t@media (prefers-color-scheme: dark) {\n\t\t\tbody {\n\t\t\t\tbackground-color: black;\n\t\t\t\tcolor: white;\n\t\t\t}\n\n\t\t\ta {\n\t\t\t\tcolor: #8f8fff;\n\t\t\t}\n\n\t\t\thr {\n\t\t\t\tborder-color: rgba(255,255,255,0.1);\n\t\t\t}\n\n\t\t\ttextarea,\n\t\t\tbutton {\n\t\t\t\tbackground-color: rgba(255, 255, 255, .15);\n\t\t\t\tcolor: white;\n\t\t\t}\n\n\t\t\tinput[type=\"submit\"] {\n\t\t\t\tbackground-color: rgba(255, 255, 255, .15);\n\t\t\t\tcolor: white;\n\t\t\t}\n\n\t\t\tmain section {\n\t\t\t\tborder: 1px solid rgba(255, 255, 255, 0.3);\n\t\t\t}\n\n\t\t\tmain section>header {\n\t\t\t\tbackground-color: rgba(255, 255, 255, 0.11);\n\t\t\t}\n\n\t\t\tlabel>span {\n\t\t\t\tcolor: rgba(255, 255, 255, .5);\n\t\t\t}\n\n\t\t\t.error {\n\t\t\t\tbackground: rgba(255, 0, 0, .7);\n\t\t\t\tcolor: white;\n\t\t\t}\n\n\t\t\t.message-incomplete, .message-empty {\n\t\t\t\tbackground-color: #4c4c10;\n\t\t\t}\n\n\t\t\t.message-changed {\n\t\t\t\tbackground-color: #00212c;\n\t\t\t}\n\n\t\t\t.message-error {\n\t\t\t\tbackground-color: darkred;\n\t\t\t}\n\n\t\t\t.selected {\n\t\t\t\tfont-weight: bold;\n\t\t\t\tcolor: white;\n\t\t\t}\n\n\t\t\t#sidebar .apply-changes {\n\t\t\t\tbackground-color: darkgreen;\n\t\t\t}\n\t\t}\n\t</style></head><body>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!doctype html><html><head><title>Toki</title><meta name=\"viewport\" content=\"width=device-width, initial-scale=1\"><meta charset=\"UTF-8\"><meta name=\"description\" content=\"Toki web GUI for editing catalogs\"><script src=\"/static/htmx_min.js\"></script><script src=\"/static/app.js\"></script><script type=\"module\" src=\"/static/mode_icu.js\"></script><script src=\"https://cdnjs.cloudflare.com/ajax/libs/codemirror/5.65.16/codemirror.min.js\"></script><link rel=\"stylesheet\" href=\"https://cdnjs.cloudflare.com/ajax/libs/codemirror/5.65.18/codemirror.min.css\"><link rel=\"stylesheet\" href=\"https://cdnjs.cloudflare.com/ajax/libs/codemirror/5.65.18/theme/base16-light.min.css\" media=\"(prefers-color-scheme: light)\"><link rel=\"stylesheet\" href=\"https://cdnjs.cloudflare.com/ajax/libs/codemirror/5.65.18/theme/base16-dark.min.css\" media=\"(prefers-color-scheme: dark)\"><style>\n\t\thtml {\n\t\t\theight: 100%;\n\t\t}\n\n\t\tbody {\n\t\t\tmargin: 0;\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: row;\n\t\t\theight: 100%;\n\t\t\tfont-family: sans-serif;\n\t\t}\n\n\t\thr {\n\t\t\twidth: 100%;\n\t\t\tborder: 0;\n\t\t\tborder-bottom: 1px solid rgba(0,0,0,.1)\n\t\t}\n\n\t\tbutton, input[type=\"submit\"], textarea, .message-changed,\n\t\t\t.message-empty, .message-incomplete, .message-error, .message-review {\n\t\t\tborder-radius: .2rem;\n\t\t}\n\n\t\t.selected {\n\t\t\tfont-weight: bold;\n\t\t\tcolor: black;\n\t\t}\n\n\t\tinput,\n\t\ttextarea,\n\t\tbutton {\n\t\t\tborder: none;\n\t\t\tpadding: .3rem;\n\t\t}\n\n\t\t.tik {\n\t\t\tfont-size: 1.4rem;\n\t\t\tline-height: 1.8rem;\n\t\t}\n\n\t\t#sidebar {\n\t\t\tmin-width: 8rem;\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tpadding: 1rem;\n\t\t\tgap: 1rem;\n\t\t\toverflow: auto;\n\t\t}\n\n\t\t#sidebar a {\n\t\t\ttext-decoration: none;\n\t\t}\n\n\t\t#sidebar > div {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tgap: .2rem;\n\t\t}\n\n\t\t#sidebar h1 {\n\t\t\tfont-size: 1.2rem;\n\t\t}\n\n\t\t#sidebar h2 {\n\t\t\tfont-size: 1rem;\n\t\t}\n\n\t\t#sidebar label input {\n\t\t\tmargin: 0;\n\t\t}\n\n\t\t#sidebar label {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: row;\n\t\t\theight: 1.5rem;\n\t\t\talign-items: center;\n\t\t}\n\n\t\t#sidebar label span {\n\t\t\tmargin-left: .5rem;\n\t\t}\n\n\t\t#sidebar .apply-changes {\n\t\t\tbackground-color: lightgreen;\n\t\t}\n\n\t\tmain {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tflex-grow: 1;\n\t\t\toverflow: auto;\n\t\t\tpadding: 1rem;\n\t\t\tpadding-left: 0;\n\t\t}\n\n\t\tmain .contents {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tgap: 1rem;\n\t\t\theight: fit-content;\n\t\t}\n\n\t\tmain .contents .no-results {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tjustify-content: center;\n\t\t\talign-items: center;\n\t\t\tmin-height: 10rem;\n\t\t}\n\n\t\tform {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tgap: .5rem;\n\t\t}\n\n\t\tlabel {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tflex: 1;\n\t\t}\n\n\t\tlabel>span {\n\t\t\tfont-weight: bold;\n\t\t\tfont-size: .8rem;\n\t\t}\n\n\t\tsection label>span {\n\t\t\tmargin-bottom: .5rem;\n\t\t}\n\n\t\tlabel .msg-id {\n\t\t\tdisplay: inline;\n\t\t\tmargin-left: .5rem;\n\t\t\tcolor: grey;\n\t\t}\n\n\t\tlabel p {\n\t\t\tmargin: 0;\n\t\t}\n\n\t\t.error {\n\t\t\tbackground: rgba(255, 0, 0, .3);\n\t\t\tcolor: black;\n\t\t\tpadding: .5rem;\n\t\t\tborder-radius: .2rem;\n\t\t\twidth: fit-content;\n\t\t\tmargin-top: .25rem;\n\t\t}\n\n\t\tmain section {\n\t\t\tmax-width: 100%;\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tborder: 1px solid rgba(0, 0, 0, .3);\n\t\t\tborder-radius: .2rem;\n\t\t}\n\n\t\tmain section>header {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\tpadding: 1rem;\n\t\t\tgap: 1rem;\n\t\t\tbackground-color: rgba(0,0,0,0.03);\n\t\t}\n\n\t\tmain section ol {\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: row;\n\t\t\tflex-wrap: nowrap;\n\t\t\toverflow-x: auto;\n\t\t\tgap: 1rem;\n\t\t\twidth: 100%;\n\t\t\tlist-style: none;\n\t\t\tmargin: 0;\n\t\t\tpadding: 1rem;\n\t\t\tbox-sizing: border-box;\n\t\t}\n\n\t\t.CodeMirror {\n\t\t\theight: auto;\n\t\t}\n\n\t\tmain section .icu-message {\n\t\t\tflex: 1 1;\n\t\t\tbox-sizing: border-box;\n\t\t\tdisplay: flex;\n\t\t\tflex-direction: column;\n\t\t\twidth: 100%;\n\t\t}\n\n\t\tmain section .icu-message textarea,\n\t\tmain section .icu-message .CodeMirror {\n\t\t\twidth: 100%;\n\t\t\tbox-sizing: border-box;\n\t\t\tmax-height: 90vh;\n\t\t\tmin-width: 14rem;\n\t\t\tmax-width: 100%;\n\t\t\tfont-size: 1rem;\n\t\t\toverflow: hidden;\n\t\t}\n\n\t\t.message-incomplete, .message-empty, .message-review {\n\t\t\tpadding: .5rem;\n\t\t\tbackground-color: beige;\n\t\t}\n\n\t\t.message-changed {\n\t\t\tpadding: .5rem;\n\t\t\tbackground-color: lightblue;\n\t\t}\n\n\t\t.message-error {\n\t\t\tpadding: .5rem;\n\t\t\tbackground-color: #ffc1c1;\n\t\t}\n\n\t\t.message-changed .no-translation {\n\t\t\tfont-style: italic;\n\t\t\topacity: 0.5;\n\t\t}\n\n\t\t.message-incomplete ul {\n\t\t\tpadding: 0;\n\t\t\tpadding-left: 1rem;\n\t\t\tbox-sizing: border-box;\n\t\t}\n\n\t\t@media (prefers-color-scheme: dark) {\n\t\t\tbody {\n\t\t\t\tbackground-color: black;\n\t\t\t\tcolor: white;\n\t\t\t}\n\n\t\t\ta {\n\t\t\t\tcolor: #8f8fff;\n\t\t\t}\n\n\t\t\thr {\n\t\t\t\tborder-color: rgba(255,255,255,0.1);\n\t\t\t}\n\n\t\t\ttextarea,\n\t\t\tbutton {\n\t\t\t\tbackground-color: rgba(255, 255, 255, .15);\n\t\t\t\tcolor: white;\n\t\t\t}\n\n\t\t\tinput[type=\"submit\"] {\n\t\t\t\tbackground-color: rgba(255, 255, 255, .15);\n\t\t\t\tcolor: white;\n\t\t\t}\n\n\t\t\tmain section {\n\t\t\t\tborder: 1px solid rgba(255, 255, 255, 0.3);\n\t\t\t}\n\n\t\t\tmain section>header {\n\t\t\t\tbackground-color: rgba(255, 255, 255, 0.11);\n\t\t\t}\n\n\t\t\tlabel>span {\n\t\t\t\tcolor: rgba(255, 255, 255, .5);\n\t\t\t}\n\n\t\t\t.error {\n\t\t\t\tbackground: rgba(255, 0, 0, .7);\n\t\t\t\tcolor: white;\n\t\t\t}\n\n\t\t\t.message-incomplete, .message-empty, .message-review {\n\t\t\t\tbackground-color: #4c4c10;\n\t\t\t}\n\n\t\t\t.message-changed {\n\t\t\t\tbackground-color: #00212c;\n\t\t\t}\n\n\t\t\t.message-error {\n\t\t\t\tbackground-color: darkred;\n\t\t\t}\n\n\t\t\t.selected {\n\t\t\t\tfont-weight: bold;\n\t\t\t\tcolor: white;\n\t\t\t}\n\n\t\t\t#sidebar .apply-changes {\n\t\t\t\tbackground-color: darkgreen;\n\t\t\t}\n\t\t}\n\t</style></head><body>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -451,22 +451,51 @@ func fragmentSection(tik TIK, catalogsDisplayed []*Catalog) templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 30, "</p></label>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 30, "</p></label> ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 31, "</header>")
+		if len(tik.Locations) > 0 {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 31, "<label><span>Used in</span><ul class=\"locations\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			for _, l := range tik.Locations {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 32, "<li><code>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var15 string
+				templ_7745c5c3_Var15, templ_7745c5c3_Err = templ.JoinStringErrs(l)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 464, Col: 20}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var15))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 33, "</code></li>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 34, "</ul></label>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 35, "</header>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		if len(tik.ICU) > 0 {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 32, "<ol>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 36, "<ol>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			for _, msg := range tik.ICU {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 33, "<li class=\"icu-message\">")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 37, "<li class=\"icu-message\">")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
@@ -474,17 +503,17 @@ func fragmentSection(tik TIK, catalogsDisplayed []*Catalog) templ.Component {
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 34, "</li>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 38, "</li>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 35, "</ol>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 39, "</ol>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 36, "</section>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 40, "</section>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -508,223 +537,242 @@ func fragmentICUMessage(tikID string, msg *ICUMessage) templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var15 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var15 == nil {
-			templ_7745c5c3_Var15 = templ.NopComponent
+		templ_7745c5c3_Var16 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var16 == nil {
+			templ_7745c5c3_Var16 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 37, "<form hx-post=\"/set\"><input type=\"hidden\" name=\"locale\" value=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 41, "<form hx-post=\"/set\"><input type=\"hidden\" name=\"locale\" value=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var16 string
-		templ_7745c5c3_Var16, templ_7745c5c3_Err = templ.JoinStringErrs(msg.Catalog.Locale)
+		var templ_7745c5c3_Var17 string
+		templ_7745c5c3_Var17, templ_7745c5c3_Err = templ.JoinStringErrs(msg.Catalog.Locale)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 474, Col: 63}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 484, Col: 63}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var16))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var17))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 38, "\"> <input type=\"hidden\" name=\"id\" value=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 42, "\"> <input type=\"hidden\" name=\"id\" value=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var17 string
-		templ_7745c5c3_Var17, templ_7745c5c3_Err = templ.JoinStringErrs(tikID)
+		var templ_7745c5c3_Var18 string
+		templ_7745c5c3_Var18, templ_7745c5c3_Err = templ.JoinStringErrs(tikID)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 475, Col: 46}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 485, Col: 46}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var17))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var18))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 39, "\"> <label>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 43, "\"> <label>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		if msg.Catalog.Default {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 40, "<span>ICU Message [")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 44, "<span>ICU Message [")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var18 string
-			templ_7745c5c3_Var18, templ_7745c5c3_Err = templ.JoinStringErrs(msg.Catalog.Locale)
+			var templ_7745c5c3_Var19 string
+			templ_7745c5c3_Var19, templ_7745c5c3_Err = templ.JoinStringErrs(msg.Catalog.Locale)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 479, Col: 38}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 489, Col: 38}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var18))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var19))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 41, " - Default] ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 45, " - Default] ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			if msg.IsReadOnly {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 42, "(read only)")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 46, "(read only)")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 43, "</span> ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 47, "</span> ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		} else {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 44, "<span>ICU Message [")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 48, "<span>ICU Message [")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var19 string
-			templ_7745c5c3_Var19, templ_7745c5c3_Err = templ.JoinStringErrs(msg.Catalog.Locale)
+			var templ_7745c5c3_Var20 string
+			templ_7745c5c3_Var20, templ_7745c5c3_Err = templ.JoinStringErrs(msg.Catalog.Locale)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 486, Col: 38}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 496, Col: 38}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var19))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var20))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 45, "] ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 49, "] ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			if msg.IsReadOnly {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 46, "(read only)")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 50, "(read only)")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 47, "</span> ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 51, "</span> ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 48, "<textarea name=\"icumsg\" class=\"editor\" data-mode=\"icu\" data-readonly=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 52, "<textarea name=\"icumsg\" class=\"editor\" data-mode=\"icu\" data-readonly=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var20 string
-		templ_7745c5c3_Var20, templ_7745c5c3_Err = templ.JoinStringErrs(msg.IsReadOnly)
+		var templ_7745c5c3_Var21 string
+		templ_7745c5c3_Var21, templ_7745c5c3_Err = templ.JoinStringErrs(msg.IsReadOnly)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 496, Col: 34}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 506, Col: 34}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var20))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var21))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 49, "\" hidden>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 53, "\" hidden>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var21 string
-		templ_7745c5c3_Var21, templ_7745c5c3_Err = templ.JoinStringErrs(msg.Message)
+		var templ_7745c5c3_Var22 string
+		templ_7745c5c3_Var22, templ_7745c5c3_Err = templ.JoinStringErrs(msg.Message)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 498, Col: 17}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 508, Col: 17}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var21))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var22))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 50, "</textarea></label> ")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 54, "</textarea></label> ")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		if msg.Changed {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 51, "<label class=\"message-changed\"><span>Original Message</span> ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 55, "<label class=\"message-changed\"><span>Original Message</span> ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			if msg.MessageOriginal != "" {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 52, "<p>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 56, "<p>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				var templ_7745c5c3_Var22 string
-				templ_7745c5c3_Var22, templ_7745c5c3_Err = templ.JoinStringErrs(msg.MessageOriginal)
+				var templ_7745c5c3_Var23 string
+				templ_7745c5c3_Var23, templ_7745c5c3_Err = templ.JoinStringErrs(msg.MessageOriginal)
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 504, Col: 29}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 514, Col: 29}
 				}
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var22))
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var23))
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 53, "</p>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 57, "</p>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			} else {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 54, "<p class=\"no-translation\">No translation</p>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 58, "<p class=\"no-translation\">No translation</p>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 55, "</label> ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 59, "</label> ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
 		if msg.Message == "" {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 56, "<span class=\"message-empty\">⚠️ Missing Translation</span> ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 60, "<span class=\"message-empty\">⚠️ Missing Translation</span> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		if msg.PreviousTIK != "" && !msg.Changed {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 61, "<label class=\"message-review\"><span>🔍 Needs Review</span><p>Carried over from the previous TIK: ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var24 string
+			templ_7745c5c3_Var24, templ_7745c5c3_Err = templ.JoinStringErrs(msg.PreviousTIK)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 526, Col: 60}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var24))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 62, "</p></label> ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
 		if msg.Error != "" {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 57, "<label class=\"message-error\"><span>🚫 Error</span><p>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 63, "<label class=\"message-error\"><span>🚫 Error</span><p>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var23 string
-			templ_7745c5c3_Var23, templ_7745c5c3_Err = templ.JoinStringErrs(msg.Error)
+			var templ_7745c5c3_Var25 string
+			templ_7745c5c3_Var25, templ_7745c5c3_Err = templ.JoinStringErrs(msg.Error)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 516, Col: 18}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 532, Col: 18}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var23))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var25))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 58, "</p></label> ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 64, "</p></label> ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
 		if len(msg.IncompleteReports) > 0 {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 59, "<label class=\"message-incomplete\"><span>⚠️ Message Incomplete</span><ul>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 65, "<label class=\"message-incomplete\"><span>⚠️ Message Incomplete</span><ul>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			for _, r := range msg.IncompleteReports {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 60, "<li>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 66, "<li>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				var templ_7745c5c3_Var24 string
-				templ_7745c5c3_Var24, templ_7745c5c3_Err = templ.JoinStringErrs(r)
+				var templ_7745c5c3_Var26 string
+				templ_7745c5c3_Var26, templ_7745c5c3_Err = templ.JoinStringErrs(r)
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 524, Col: 13}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 540, Col: 13}
 				}
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var24))
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var26))
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 61, "</li>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 67, "</li>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 62, "</ul></label> ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 68, "</ul></label> ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
 		if !msg.IsReadOnly {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 63, "<input type=\"submit\" value=\"Update\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 69, "<input type=\"submit\" value=\"Update\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 64, "</form>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 70, "</form>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -748,71 +796,71 @@ func fragmentRadioOption(isSelected bool, name, value, label string) templ.Compo
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var25 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var25 == nil {
-			templ_7745c5c3_Var25 = templ.NopComponent
+		templ_7745c5c3_Var27 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var27 == nil {
+			templ_7745c5c3_Var27 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 65, "<label")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 71, "<label")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		if isSelected {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 66, " class=\"selected\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 72, " class=\"selected\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 67, "><input type=\"radio\" name=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 73, "><input type=\"radio\" name=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var26 string
-		templ_7745c5c3_Var26, templ_7745c5c3_Err = templ.JoinStringErrs(name)
+		var templ_7745c5c3_Var28 string
+		templ_7745c5c3_Var28, templ_7745c5c3_Err = templ.JoinStringErrs(name)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 543, Col: 14}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 559, Col: 14}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var26))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var28))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 68, "\" value=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 74, "\" value=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var27 string
-		templ_7745c5c3_Var27, templ_7745c5c3_Err = templ.JoinStringErrs(value)
+		var templ_7745c5c3_Var29 string
+		templ_7745c5c3_Var29, templ_7745c5c3_Err = templ.JoinStringErrs(value)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 544, Col: 16}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 560, Col: 16}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var27))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var29))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 69, "\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 75, "\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		if isSelected {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 70, " checked")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 76, " checked")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 71, "> <span>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 77, "> <span>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var28 string
-		templ_7745c5c3_Var28, templ_7745c5c3_Err = templ.JoinStringErrs(label)
+		var templ_7745c5c3_Var30 string
+		templ_7745c5c3_Var30, templ_7745c5c3_Err = templ.JoinStringErrs(label)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 549, Col: 15}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/webedit/template/template.templ`, Line: 565, Col: 15}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var28))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var30))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 72, "</span></label>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 78, "</span></label>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -102,6 +102,7 @@ func (s *Server) Init() (err error) {
 			ID:          t.IDHash,
 			TIK:         t.TIK.Raw,
 			Description: strings.Join(t.Comments, " "),
+			Locations:   t.Locations(),
 			ICU:         make([]*template.ICUMessage, 0, len(s.catalogs)),
 		}
 		for c := range s.scan.Catalogs.SeqRead() {
@@ -126,6 +127,9 @@ func (s *Server) Init() (err error) {
 				Message:    m.ICUMessage,
 				IsReadOnly: isReadOnly,
 			}
+			if prev, ok := m.CustomAttributes[codeparse.AttrFuzzy].(string); ok {
+				tmplMsg.PreviousTIK = prev
+			}
 			tmplTIK.ICU = append(tmplTIK.ICU, tmplMsg)
 		}
 		s.tiks = append(s.tiks, tmplTIK)
@@ -222,9 +226,10 @@ func (s *Server) handlePostSet(w http.ResponseWriter, r *http.Request) {
 	})
 	icuMsg := tk.ICU[iICUMsg]
 
-	if icuMsg.Message == newMessage {
+	if icuMsg.Message == newMessage && (icuMsg.PreviousTIK == "" || icuMsg.Changed) {
+		// No change. Resubmitting a message that needs review confirms it instead.
 		template.RenderFragmentICUMessage(w, r, id, icuMsg)
-		return // No change.
+		return
 	}
 
 	loc := s.localeTags[iCatalog]
@@ -375,6 +380,7 @@ func (s *Server) newDataIndex(
 			ID:          tk.ID,
 			TIK:         tk.TIK,
 			Description: tk.Description,
+			Locations:   tk.Locations,
 			ICU:         make([]*template.ICUMessage, 0, len(s.catalogs)),
 		}
 		isInvalid := false
@@ -418,6 +424,10 @@ func (s *Server) newDataIndex(
 				isIncomplete = true
 			}
 
+			if m.PreviousTIK != "" && !m.Changed {
+				isIncomplete = true // Needs review.
+			}
+
 			if m.Changed {
 				isChanged = true
 			}
@@ -522,6 +532,7 @@ func (s *Server) handlePostApplyChanges(w http.ResponseWriter, r *http.Request)
 		m := arbFile.Messages
 		arbMsg := m[c.ID]
 		arbMsg.ICUMessage = c.Message
+		delete(arbMsg.CustomAttributes, codeparse.AttrFuzzy) // Reviewed.
 
 		m[c.ID] = arbMsg
 		arbFiles[c.Catalog.Locale] = arbFile
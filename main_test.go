@@ -2,18 +2,24 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/romshark/toki/internal/app"
 	"github.com/romshark/toki/internal/arb"
+	"github.com/romshark/toki/internal/codeparse"
+	"github.com/romshark/toki/internal/config"
 
 	"github.com/romshark/tik/tik-go"
 	"github.com/stretchr/testify/require"
@@ -24,7 +30,7 @@ func TestVersion(t *testing.T) {
 	var stderr, stdout bytes.Buffer
 
 	res, exitCode := app.Run(
-		[]string{"toki", "version"}, osEnv(), &stderr, &stdout, TimeNow,
+		[]string{"toki", "version"}, osEnv(t), &stderr, &stdout, TimeNow,
 	)
 	require.Equal(t, 0, exitCode)
 	require.Zero(t, res)
@@ -91,7 +97,7 @@ func TestGenerateAndRun(t *testing.T) {
 
 	runInDir(t, dir, func() {
 		args := []string{"toki", "generate", "-l=en"}
-		result, exitCode := app.Run(args, osEnv(), io.Discard, io.Discard, TimeNow)
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
 		require.NoError(t, result.Err)
 		require.Zero(t, exitCode)
 	})
@@ -170,14 +176,14 @@ func TestGenerateAndRunFallback(t *testing.T) {
 
 	runInDir(t, dir, func() {
 		args := []string{"toki", "generate", "-l=en", "-t=de"}
-		result, exitCode := app.Run(args, osEnv(), io.Discard, io.Discard, TimeNow)
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
 		require.NoError(t, result.Err)
 		require.Zero(t, exitCode)
 	})
 
 	runInDir(t, dir, func() {
 		args := []string{"toki", "generate"}
-		result, exitCode := app.Run(args, osEnv(), io.Discard, io.Discard, TimeNow)
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
 		require.NoError(t, result.Err)
 		require.Zero(t, exitCode)
 	})
@@ -222,14 +228,14 @@ func TestGenerateAndRunNoTranslationFallback(t *testing.T) {
 
 	runInDir(t, dir, func() {
 		args := []string{"toki", "generate", "-l=en", "-t=de"}
-		result, exitCode := app.Run(args, osEnv(), io.Discard, io.Discard, TimeNow)
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
 		require.NoError(t, result.Err)
 		require.Zero(t, exitCode)
 	})
 
 	runInDir(t, dir, func() {
 		args := []string{"toki", "generate"}
-		result, exitCode := app.Run(args, osEnv(), io.Discard, io.Discard, TimeNow)
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
 		require.NoError(t, result.Err)
 		require.Zero(t, exitCode)
 	})
@@ -425,69 +431,2077 @@ func TestGenerate(t *testing.T) {
 	}
 }
 
+// TestGenerateDirectives verifies that comment directives above a call
+// are written to the catalogs as structured metadata.
+func TestGenerateDirectives(t *testing.T) {
+	dir := t.TempDir()
+	initGoMod(t, dir, ModName)
+	_ = initBundle(t, dir, language.English, "tokibundle", io.Discard, io.Discard)
+
+	mainGo := func(maxLen string) map[string]string {
+		return map[string]string{"main.go": `
+			package main
+			import "tstmod/tokibundle"
+			func main() {
+				// Label of the checkout button.
+				//toki:context checkout page
+				//toki:maxlen ` + maxLen + `
+				//toki:example var0=Alice
+				//toki:screenshot docs/checkout.png
+				print(tokibundle.Default().String("Pay for {text}", "Bob"))
+			}
+		`}
+	}
+	generate := func(t *testing.T) arb.Message {
+		t.Helper()
+		var result app.Result
+		runInDir(t, dir, func() {
+			var exitCode int
+			result, exitCode = app.Run([]string{"toki", "generate", "-t", "de"},
+				osEnv(t), io.Discard, io.Discard, TimeNow)
+			require.NoError(t, result.Err)
+			require.Zero(t, exitCode)
+		})
+		require.Equal(t, 1, result.Scan.Texts.Len())
+		text := result.Scan.Texts.At(0)
+		require.Equal(t, []string{"Label of the checkout button."}, text.Comments)
+
+		native := readARBFile(t, filepath.Join(dir, "tokibundle/catalog_en.arb"))
+		require.Contains(t, native.Messages, text.IDHash)
+		return native.Messages[text.IDHash]
+	}
+
+	writeFiles(t, dir, mainGo("24"))
+	msg := generate(t)
+	require.Equal(t, "Label of the checkout button.", msg.Description)
+	require.Equal(t, "checkout page", msg.Context)
+	require.Equal(t, "Alice", msg.Placeholders["var0"].Example)
+	require.Equal(t, float64(24), msg.CustomAttributes[codeparse.AttrMaxLen])
+	require.Equal(t, []any{"docs/checkout.png"},
+		msg.CustomAttributes[codeparse.AttrScreenshots])
+
+	de := readARBFile(t, filepath.Join(dir, "tokibundle/catalog_de.arb"))
+	require.Equal(t, "checkout page", de.Messages[msg.ID].Context)
+	require.Equal(t, float64(24), de.Messages[msg.ID].CustomAttributes[codeparse.AttrMaxLen])
+
+	// Changed directives update the existing message.
+	writeFiles(t, dir, mainGo("32"))
+	msg = generate(t)
+	require.Equal(t, float64(32), msg.CustomAttributes[codeparse.AttrMaxLen])
+}
+
+// TestGenerateDirectivesMerged verifies that the directives of all call sites
+// of a text are merged and that conflicting directives are reported.
+func TestGenerateDirectivesMerged(t *testing.T) {
+	dir := t.TempDir()
+	initGoMod(t, dir, ModName)
+	_ = initBundle(t, dir, language.English, "tokibundle", io.Discard, io.Discard)
+
+	generate := func(t *testing.T, second string) app.Result {
+		t.Helper()
+		writeFiles(t, dir, map[string]string{"main.go": `
+			package main
+			import "tstmod/tokibundle"
+			func main() {
+				r := tokibundle.Default()
+				//toki:context checkout page
+				//toki:maxlen 24
+				//toki:example var0=Alice
+				//toki:screenshot docs/checkout.png
+				print(r.String("Pay for {text}", "Bob"))
+				` + second + `
+				print(r.String("Pay for {text}", "Bob"))
+			}
+		`})
+		var result app.Result
+		runInDir(t, dir, func() {
+			result, _ = app.Run([]string{"toki", "generate", "-t", "de"},
+				osEnv(t), io.Discard, io.Discard, TimeNow)
+		})
+		return result
+	}
+
+	result := generate(t, "")
+	require.NoError(t, result.Err)
+
+	// The directives of the second call site reach all catalogs.
+	result = generate(t, "//toki:maxlen 16\n//toki:screenshot docs/cart.png")
+	require.NoError(t, result.Err)
+	require.Equal(t, 1, result.Scan.Texts.Len())
+	for _, name := range []string{"catalog_en.arb", "catalog_de.arb"} {
+		catalog := readARBFile(t, filepath.Join(dir, "tokibundle", name))
+		msg := catalog.Messages[result.Scan.Texts.At(0).IDHash]
+		require.Equal(t, "checkout page", msg.Context, name)
+		require.Equal(t, "Alice", msg.Placeholders["var0"].Example, name)
+		require.Equal(t, float64(16), msg.CustomAttributes[codeparse.AttrMaxLen], name)
+		require.Equal(t, []any{"docs/checkout.png", "docs/cart.png"},
+			msg.CustomAttributes[codeparse.AttrScreenshots], name)
+	}
+
+	result = generate(t, "//toki:context cart\n//toki:example var0=Bob")
+	require.ErrorIs(t, result.Err, app.ErrSourceErrors)
+	require.Equal(t, 2, result.Scan.SourceErrors.Len())
+	for e := range result.Scan.SourceErrors.SeqRead() {
+		require.ErrorIs(t, e.Err, codeparse.ErrDirectiveConflict)
+		require.Equal(t, 12, e.Line)
+	}
+	require.Equal(t, `conflicting directive: //toki:context: `+
+		`"cart" differs from "checkout page" (at main.go:9)`,
+		result.Scan.SourceErrors.At(0).Err.Error())
+}
+
+// TestLintMaxLength verifies that translations exceeding the maximum length
+// declared with //toki:maxlen are reported at the catalog and message.
+func TestLintMaxLength(t *testing.T) {
+	dir := t.TempDir()
+	initGoMod(t, dir, ModName)
+	_ = initBundle(t, dir, language.English, "tokibundle", io.Discard, io.Discard)
+	writeFiles(t, dir, map[string]string{"main.go": `
+		package main
+		import "tstmod/tokibundle"
+		func main() {
+			//toki:maxlen 12
+			print(tokibundle.Default().String("Save changes"))
+			//toki:maxwidth 16
+			//toki:example var0=Alice
+			print(tokibundle.Default().String("Hi {text}, {# messages}", "Bob", 2))
+			//toki:maxlen 20
+			print(tokibundle.Default().String("Welcome {text}", "Bob"))
+		}
+	`})
+
+	run := func(t *testing.T, cmd string) app.Result {
+		t.Helper()
+		var result app.Result
+		runInDir(t, dir, func() {
+			result, _ = app.Run([]string{"toki", cmd, "-t", "de"},
+				osEnv(t), io.Discard, io.Discard, TimeNow)
+		})
+		return result
+	}
+
+	// "Hi Alice, 999,999 messages" is 26 cells wide
+	// and "Welcome {text}" lacks an example to be measured.
+	result := run(t, "generate")
+	require.ErrorIs(t, result.Err, app.ErrSourceErrors)
+	require.Equal(t, 2, result.Scan.SourceErrors.Len())
+	e := result.Scan.SourceErrors.At(0)
+	require.ErrorIs(t, e.Err, app.ErrMaxLenNoExample)
+	require.Contains(t, e.Err.Error(), "add //toki:example var0=...")
+	require.Equal(t, "main.go", filepath.Base(e.Filename))
+	require.Equal(t, 10, e.Line)
+	e = result.Scan.SourceErrors.At(1)
+	require.ErrorIs(t, e.Err, app.ErrMaxLenExceeded)
+	require.Contains(t, e.Err.Error(), "may be up to 26 cells wide, maximum is 16")
+	require.Equal(t, "catalog_en.arb", filepath.Base(e.Filename))
+
+	writeFiles(t, dir, map[string]string{"main.go": `
+		package main
+		import "tstmod/tokibundle"
+		func main() {
+			//toki:maxlen 12
+			print(tokibundle.Default().String("Save changes"))
+			//toki:maxwidth 16
+			//toki:example var0=Alice
+			print(tokibundle.Default().String("Hi {text}, {# messages}", "Bob", 2))
+		}
+	`})
+	result = run(t, "generate")
+	require.ErrorIs(t, result.Err, app.ErrSourceErrors)
+	require.Equal(t, 1, result.Scan.SourceErrors.Len())
+
+	// Fix the native message and break the German translation.
+	pathDE := filepath.Join(dir, "tokibundle/catalog_de.arb")
+	pathEN := filepath.Join(dir, "tokibundle/catalog_en.arb")
+	en, de := readARBFile(t, pathEN), readARBFile(t, pathDE)
+	var idSave string
+	for id, msg := range en.Messages {
+		if msg.ICUMessage == "Save changes" {
+			idSave = id
+			continue
+		}
+		msg.ICUMessage = "Hi {var0}"
+		en.Messages[id] = msg
+		de.Messages[id] = arb.Message{ID: id, ICUMessage: "Hallo {var0}"}
+	}
+	de.Messages[idSave] = arb.Message{ID: idSave, ICUMessage: "Änderungen speichern"}
+	writeARBFile(t, pathEN, en)
+	writeARBFile(t, pathDE, de)
+
+	result = run(t, "lint")
+	require.ErrorIs(t, result.Err, app.ErrSourceErrors)
+	require.Equal(t, 1, result.Scan.SourceErrors.Len())
+	e = result.Scan.SourceErrors.At(0)
+	require.ErrorIs(t, e.Err, app.ErrMaxLenExceeded)
+	require.Equal(t, idSave+" (de) may be up to 20 characters, maximum is 12",
+		strings.TrimPrefix(e.Err.Error(), app.ErrMaxLenExceeded.Error()+": "))
+	require.Equal(t, filepath.FromSlash("/tokibundle/catalog_de.arb"), e.Filename)
+	require.Equal(t, pathDE, result.Scan.UntrimPath(e.Filename))
+	data, err := os.ReadFile(pathDE)
+	require.NoError(t, err)
+	require.Contains(t, strings.Split(string(data), "\n")[e.Line-1], `"`+idSave+`":`)
+}
+
+// TestGenerateLocations verifies that the positions of all call sites
+// of a message are listed in every catalog.
+func TestLintNearDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	initGoMod(t, dir, ModName)
+	_ = initBundle(t, dir, language.English, "tokibundle", io.Discard, io.Discard)
+	writeFiles(t, dir, map[string]string{
+		".tokidomain.yml": "name: root\ndescription: \"\"",
+		"main.go": `
+			package main
+			import "tstmod/tokibundle"
+			func main() {
+				r := tokibundle.Default()
+				print(r.String("Save changes"))
+				print(r.String("Save Changes."))
+				print(r.String("save  changes"))
+				print(r.String("Save changes"))
+				print(r.String("Hello {text}!", "Bob"), r.String("hello {name}", tokibundle.String{}))
+				print(r.String("Delete {# items}", 1), r.String("Delete {# item}", 1))
+				print(r.String("Save"), r.String("Sale"))
+			}
+		`,
+		"sub/.tokidomain.yml": "name: sub\ndescription: \"\"",
+		"sub/sub.go": `
+			package sub
+			import "tstmod/tokibundle"
+			func Save() string { return tokibundle.Default().String("Save changes!") }
+		`,
+	})
+
+	var result app.Result
+	runInDir(t, dir, func() {
+		var exitCode int
+		result, exitCode = app.Run([]string{"toki", "lint"},
+			osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.NoError(t, result.Err)
+		require.Zero(t, exitCode) // Near-duplicates are only warnings.
+	})
+
+	type group struct {
+		Keep  string
+		Merge []string
+	}
+	var groups []group
+	for _, g := range result.NearDuplicates {
+		gr := group{Keep: g.Keep.TIK.Raw}
+		for _, m := range g.Merge {
+			gr.Merge = append(gr.Merge, m.TIK.Raw)
+		}
+		groups = append(groups, gr)
+	}
+	// "Save changes" is kept since it has the most call sites.
+	// "Save changes!" is in a different domain, "Save" and "Sale" are too short.
+	require.Equal(t, []group{
+		{Keep: "Save changes", Merge: []string{"Save Changes.", "save  changes"}},
+		{Keep: "Hello {text}!", Merge: []string{"hello {name}"}},
+		{Keep: "Delete {# items}", Merge: []string{"Delete {# item}"}},
+	}, groups)
+	require.Equal(t, []string{"main.go:6"}, result.NearDuplicates[0].Merge[0].Locations())
+}
+
+func TestGenerateTempl(t *testing.T) {
+	dir := t.TempDir()
+	initGoMod(t, dir, ModName)
+	_ = initBundle(t, dir, language.English, "tokibundle", io.Discard, io.Discard)
+	writeFiles(t, dir, map[string]string{
+		"main.go": `
+			package main
+			import "tstmod/tokibundle"
+			func main() { _ = page(tokibundle.Default()) }
+		`,
+		"page.templ": `
+package main
+
+import "tstmod/tokibundle"
+
+templ page(r tokibundle.Reader) {
+	// Title of the settings page.
+	//toki:maxlen 20
+	<h1>{ r.String("Settings") }</h1>
+	<p>{ r.String("You have {# messages}", 5) }</p>
+}
+`,
+	})
+	for _, args := range [][]string{
+		{"go", "get", "github.com/a-h/templ@v0.3.1001"},
+		{"go", "run", "github.com/a-h/templ/cmd/templ", "generate"},
+	} {
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = dir
+		cmd.Env = osEnv(t)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	var result app.Result
+	runInDir(t, dir, func() {
+		var exitCode int
+		result, exitCode = app.Run([]string{"toki", "generate"},
+			osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.NoError(t, result.Err)
+		require.Zero(t, exitCode)
+	})
+
+	texts := make(map[string]codeparse.Text)
+	for text := range result.Scan.Texts.SeqRead() {
+		texts[text.TIK.Raw] = text
+	}
+	require.Len(t, texts, 2)
+	settings := texts["Settings"]
+	require.Equal(t, "page.templ", filepath.Base(settings.Position.Filename))
+	require.Equal(t, 8, settings.Position.Line)
+	require.Equal(t, 8, settings.Position.Column)
+	require.Equal(t, []string{"Title of the settings page."}, settings.Comments)
+	require.Equal(t, 20, settings.Metadata.MaxLen)
+	require.Equal(t, []string{"page.templ:9"}, texts["You have {# messages}"].Locations())
+
+	// Source errors refer to the .templ file as well.
+	writeFiles(t, dir, map[string]string{"page.templ": `
+package main
+
+import "tstmod/tokibundle"
+
+templ page(r tokibundle.Reader) {
+	<h1>{ r.String("Hello {text}") }</h1>
+}
+`})
+	cmd := exec.Command("go", "run", "github.com/a-h/templ/cmd/templ", "generate")
+	cmd.Dir = dir
+	cmd.Env = osEnv(t)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	runInDir(t, dir, func() {
+		result, _ = app.Run([]string{"toki", "lint"},
+			osEnv(t), io.Discard, io.Discard, TimeNow)
+	})
+	require.ErrorIs(t, result.Err, app.ErrSourceErrors)
+	require.Equal(t, 1, result.Scan.SourceErrors.Len())
+	e := result.Scan.SourceErrors.At(0)
+	require.Equal(t, "page.templ", filepath.Base(e.Filename))
+	require.Equal(t, 6, e.Line)
+	require.Equal(t, 17, e.Column)
+}
+
+func TestGenerateTemplates(t *testing.T) {
+	dir := t.TempDir()
+	initGoMod(t, dir, ModName)
+	writeFiles(t, dir, map[string]string{
+		".toki.yml": "templates: [templates/*.html]",
+		"main.go": `
+			package main
+			import (
+				"html/template"
+				"os"
+				"tstmod/tokibundle"
+			)
+			func main() {
+				tmpl := template.Must(template.New("inbox.html").
+					Funcs(tokibundle.FuncMap(tokibundle.Default())).
+					ParseFiles("templates/inbox.html"))
+				data := map[string]any{"Name": "<Alice>", "Count": 3}
+				if err := tmpl.Execute(os.Stdout, data); err != nil {
+					panic(err)
+				}
+			}
+		`,
+		"templates/inbox.html": `
+{{/* Greeting on the inbox page. */}}
+{{/* toki:maxlen 40 */}}
+{{/* toki:example var0=Alice */}}
+<h1>{{ t "Hello {text}" .Name }}</h1>
+<p>{{ .Count | t "You have {# messages}" }}</p>
+`,
+	})
+
+	var result app.Result
+	runInDir(t, dir, func() {
+		var exitCode int
+		result, exitCode = app.Run([]string{"toki", "generate", "-l=en"},
+			osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.NoError(t, result.Err)
+		require.Zero(t, exitCode)
+	})
+
+	texts := make(map[string]codeparse.Text)
+	for text := range result.Scan.Texts.SeqRead() {
+		texts[text.TIK.Raw] = text
+	}
+	require.Len(t, texts, 2)
+	hello := texts["Hello {text}"]
+	require.Equal(t, []string{"templates/inbox.html:4"}, hello.Locations())
+	require.Equal(t, 8, hello.Position.Column)
+	require.Equal(t, []string{"Greeting on the inbox page."}, hello.Comments)
+	require.Equal(t, 40, hello.Metadata.MaxLen)
+	require.Equal(t, []string{"templates/inbox.html:5"},
+		texts["You have {# messages}"].Locations())
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = osEnv(t)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.Contains(t, string(out), "<h1>Hello &lt;Alice&gt;</h1>")
+	require.Contains(t, string(out), "<p>You have 3 messages</p>")
+
+	// Arguments are checked against the placeholders.
+	writeFiles(t, dir, map[string]string{"templates/inbox.html": `
+<h1>{{ t "Hello {text}" }}</h1>
+<p>{{ t "Bye" .Name }}</p>
+`})
+	runInDir(t, dir, func() {
+		result, _ = app.Run([]string{"toki", "lint"},
+			osEnv(t), io.Discard, io.Discard, TimeNow)
+	})
+	require.ErrorIs(t, result.Err, app.ErrSourceErrors)
+	var errs []string
+	for e := range result.Scan.SourceErrors.SeqRead() {
+		errs = append(errs, fmt.Sprintf("%s:%d:%d: %v",
+			filepath.Base(e.Filename), e.Line, e.Column, e.Err))
+	}
+	require.Equal(t, []string{
+		"inbox.html:1:10: TIK: missing argument 0 for placeholder (text)",
+		"inbox.html:2:9: TIK: arg 0 doesn't match any TIK placeholder",
+	}, errs)
+}
+
+func TestGenerateLocations(t *testing.T) {
+	dir := t.TempDir()
+	initGoMod(t, dir, ModName)
+	_ = initBundle(t, dir, language.English, "tokibundle", io.Discard, io.Discard)
+	writeFiles(t, dir, map[string]string{
+		"main.go": `
+			package main
+			import "tstmod/tokibundle"
+			func main() {
+				r := tokibundle.Default()
+				print(r.String("Save"), r.String("Cancel"))
+				print(r.String("Save"))
+				print(other())
+			}
+		`,
+		"other.go": `
+			package main
+			import "tstmod/tokibundle"
+			func other() string { return tokibundle.Default().String("Save") }
+		`,
+	})
+
+	var result app.Result
+	runInDir(t, dir, func() {
+		var exitCode int
+		result, exitCode = app.Run([]string{"toki", "generate", "-t", "de"},
+			osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.NoError(t, result.Err)
+		require.Zero(t, exitCode)
+	})
+
+	locations := map[string][]any{
+		"Save":   {"main.go:5", "main.go:6", "other.go:3"},
+		"Cancel": {"main.go:5"},
+	}
+	for text := range result.Scan.Texts.SeqRead() {
+		for _, locale := range []string{"en", "de"} {
+			f := readARBFile(t, filepath.Join(dir, "tokibundle/catalog_"+locale+".arb"))
+			require.Equal(t, locations[text.TIK.Raw],
+				f.Messages[text.IDHash].CustomAttributes[codeparse.AttrLocations],
+				"TIK %q in catalog %q", text.TIK.Raw, locale)
+		}
+	}
+}
+
 // TestGenerateDomainFileNotOverwritten verifies that an existing .tokidomain.yml file
 // is preserved when running `toki generate` again.
 func TestGenerateDomainFileNotOverwritten(t *testing.T) {
 	dir := t.TempDir()
 	initGoMod(t, dir, "tstmod")
 
-	customDomain := "name: custom\ndescription: my custom domain"
+	customDomain := "name: custom\ndescription: my custom domain"
+	writeFiles(t, dir, map[string]string{
+		".tokidomain.yml": customDomain,
+	})
+
+	runInDir(t, dir, func() {
+		args := []string{"toki", "generate", "-l=en"}
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.NoError(t, result.Err)
+		require.Zero(t, exitCode)
+	})
+
+	// Verify .tokidomain.yml was not overwritten.
+	content, err := os.ReadFile(filepath.Join(dir, ".tokidomain.yml"))
+	require.NoError(t, err)
+	require.Equal(t, customDomain, string(content))
+}
+
+// TestGenerateSameTIKDifferentDomains verifies that the same TIK string used in
+// different domains (including parent vs. sub-domain) does not cause a collision.
+func TestGenerateSameTIKDifferentDomains(t *testing.T) {
+	dir := t.TempDir()
+	initGoMod(t, dir, "tstmod")
+	writeFiles(t, dir, map[string]string{
+		".tokidomain.yml": "name: root\ndescription: \"\"",
+		"main.go": `
+			package main
+			import "fmt"
+			import "tstmod/tokibundle"
+			import "golang.org/x/text/language"
+			func main() {
+				r, _ := tokibundle.Match(language.English)
+				fmt.Println(r.String("hello world"))
+			}
+		`,
+		"sub/.tokidomain.yml": "name: sub\ndescription: \"\"",
+		"sub/sub.go": `
+			package sub
+			import "tstmod/tokibundle"
+			import "golang.org/x/text/language"
+			func Hello() string {
+				r, _ := tokibundle.Match(language.English)
+				return r.String("hello world")
+			}
+		`,
+	})
+
+	runInDir(t, dir, func() {
+		args := []string{"toki", "generate", "-l=en"}
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.NoError(t, result.Err)
+		require.Zero(t, exitCode)
+		// The same TIK in different domains must produce distinct message IDs.
+		require.Zero(t, result.Scan.SourceErrors.Len(),
+			"expected no source errors (no collision)")
+		require.Equal(t, 2, result.Scan.Texts.Len(),
+			"expected 2 distinct texts from 2 different domains")
+	})
+}
+
+// TestGenerateConfigFile verifies that settings are read from .toki.yml
+// and that CLI flags take precedence over them.
+func TestGenerateConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	initGoMod(t, dir, "tstmod")
+	writeFiles(t, dir, map[string]string{
+		".toki.yml": "locale: en\n" +
+			"translations: [de]\n" +
+			"bundle: pkg/i18n\n" +
+			"require-complete: true\n",
+	})
+
+	runInDir(t, dir, func() {
+		args := []string{"toki", "generate"}
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.NoError(t, result.Err)
+		require.Zero(t, exitCode)
+		require.Equal(t, language.English, result.Config.Locale)
+		require.Equal(t, []language.Tag{language.German}, result.Config.Translations)
+		require.True(t, result.Config.RequireComplete)
+	})
+	require.FileExists(t, filepath.Join(dir, "pkg/i18n/catalog_en.arb"))
+	require.FileExists(t, filepath.Join(dir, "pkg/i18n/catalog_de.arb"))
+
+	writeFiles(t, dir, map[string]string{
+		"main.go": `
+			package main
+			import "tstmod/pkg/i18n"
+			func main() { print(i18n.Default().String("There are {# errors}", 0)) }
+		`,
+	})
+
+	runInDir(t, dir, func() {
+		args := []string{"toki", "lint"}
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.ErrorIs(t, result.Err, app.ErrBundleIncomplete)
+		require.Equal(t, 1, exitCode)
+
+		// Flags override the configuration file.
+		args = []string{"toki", "lint", "-require-complete=false"}
+		result, exitCode = app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.NoError(t, result.Err)
+		require.Zero(t, exitCode)
+	})
+
+	// Unknown keys are reported with their position.
+	writeFiles(t, dir, map[string]string{
+		".toki.yml": "locale: en\nbundel: pkg/i18n\n",
+	})
+	runInDir(t, dir, func() {
+		args := []string{"toki", "generate"}
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.ErrorIs(t, result.Err, config.ErrFileInvalid)
+		require.Contains(t, result.Err.Error(), `[2:1] unknown field "bundel"`)
+		require.Equal(t, 2, exitCode)
+	})
+}
+
+// TestGenerateMultipleBundles verifies that independent bundles in one module
+// get their own catalogs and that call sites are attributed to the bundle
+// whose Reader they use.
+func TestGenerateMultipleBundles(t *testing.T) {
+	dir := t.TempDir()
+	initGoMod(t, dir, "tstmod")
+	writeFiles(t, dir, map[string]string{
+		".toki.yml": "locale: en\n" +
+			"bundles:\n" +
+			"  - path: webbundle\n" +
+			"    translations: [de, fr]\n" +
+			"  - path: email/bundle\n" +
+			"    translations: [de]\n",
+	})
+
+	generate := func(t *testing.T) app.Result {
+		t.Helper()
+		var result app.Result
+		runInDir(t, dir, func() {
+			var exitCode int
+			args := []string{"toki", "generate"}
+			result, exitCode = app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+			require.NoError(t, result.Err)
+			require.Zero(t, exitCode)
+		})
+		return result
+	}
+
+	result := generate(t)
+	require.Len(t, result.Bundles, 2)
+
+	writeFiles(t, dir, map[string]string{
+		"main.go": `
+			package main
+			import (
+				"tstmod/email/bundle"
+				"tstmod/webbundle"
+			)
+			func main() {
+				web, email := webbundle.Default(), bundle.Default()
+				print(web.String("Welcome"), web.String("Sign in"))
+				print(email.String("Welcome"), email.String("Confirm your email"))
+			}
+		`,
+	})
+	_ = generate(t)
+	result = generate(t)
+	require.Len(t, result.Bundles, 2)
+
+	tiks := func(scan *codeparse.Scan) []string {
+		var l []string
+		for text := range scan.Texts.SeqRead() {
+			l = append(l, text.TIK.Raw)
+		}
+		slices.Sort(l)
+		return l
+	}
+	require.Equal(t, "webbundle", result.Bundles[0].Bundle)
+	require.Equal(t, []string{"Sign in", "Welcome"}, tiks(result.Bundles[0].Scan))
+	require.Equal(t, "email/bundle", result.Bundles[1].Bundle)
+	require.Equal(t,
+		[]string{"Confirm your email", "Welcome"}, tiks(result.Bundles[1].Scan))
+
+	for _, f := range []string{
+		"webbundle/catalog_en.arb",
+		"webbundle/catalog_de.arb",
+		"webbundle/catalog_fr.arb",
+		"email/bundle/catalog_en.arb",
+		"email/bundle/catalog_de.arb",
+	} {
+		require.FileExists(t, filepath.Join(dir, f))
+	}
+	require.NoFileExists(t, filepath.Join(dir, "email/bundle/catalog_fr.arb"))
+	require.Len(t, readARBFile(t, filepath.Join(dir, "webbundle/catalog_de.arb")).Messages, 2)
+	require.Len(t, readARBFile(t, filepath.Join(dir, "email/bundle/catalog_de.arb")).Messages, 2)
+
+	// The bundles compile and run.
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = osEnv(t)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.Equal(t, "WelcomeSign inWelcomeConfirm your email", string(out))
+}
+
+// TestGenerateForward verifies that calls to functions annotated with
+// //toki:forward are treated like calls to the Reader methods.
+func TestGenerateForward(t *testing.T) {
+	_, resLint, resGenerate := Setup{
+		InitGoMod: true, InitBundle: true,
+		FilesAfterInit: map[string]string{
+			"main.go": `
+			package main
+			import (
+				"os"
+				"tstmod/tokibundle"
+			)
+			type Handler struct{ r tokibundle.Reader }
+			//toki:forward tik=1 args=2
+			func (h *Handler) t(prefix string, tik tokibundle.TIK, args ...any) string {
+				return prefix + h.r.String(tik, args...)
+			}
+			// Forwarders may forward to other forwarders.
+			//toki:forward tik=0 args=1
+			func (h *Handler) title(tik tokibundle.TIK, args ...any) string {
+				return h.t("# ", tik, args...)
+			}
+			//toki:forward tik=0 args=1
+			func write(tik string, args ...any) {
+				_, _ = tokibundle.Default().Write(os.Stdout, tokibundle.TIK(tik), args...)
+			}
+			func main() {
+				h := &Handler{r: tokibundle.Default()}
+				print(h.t("", "Hello {text}", "Alice"))
+				print(h.title("You have {# messages}", 3))
+				write("Goodbye")
+			}
+			`,
+		},
+	}.generate(t, TimeNow, "-l=en")
+	for _, res := range []RunResult{resLint, resGenerate} {
+		require.NoError(t, res.Err)
+		require.Zero(t, res.ExitCode)
+		var tiks []string
+		for text := range res.Scan.Texts.SeqRead() {
+			tiks = append(tiks, text.TIK.Raw)
+		}
+		slices.Sort(tiks)
+		require.Equal(t,
+			[]string{"Goodbye", "Hello {text}", "You have {# messages}"}, tiks)
+		require.Equal(t, int64(2), res.Scan.StringCalls.Load())
+		require.Equal(t, int64(1), res.Scan.WriteCalls.Load())
+	}
+}
+
+// TestGenerateTypedTIK verifies that constant TIK values are extracted
+// wherever they're declared and may be passed to Reader dynamically.
+func TestGenerateTypedTIK(t *testing.T) {
+	_, resLint, resGenerate := Setup{
+		InitGoMod: true, InitBundle: true,
+		FilesAfterInit: map[string]string{
+			"main.go": `
+			package main
+			import "tstmod/tokibundle"
+			type Status int
+			const (
+				StatusActive Status = iota
+				StatusBanned
+			)
+			// Shown when the account is locked.
+			const locked tokibundle.TIK = "Locked"
+			var statusLabels = map[Status]tokibundle.TIK{
+				// Label of accounts in good standing.
+				StatusActive: "Active",
+				StatusBanned: "Banned",
+			}
+			func main() {
+				r := tokibundle.Default()
+				for s, label := range statusLabels {
+					if label != "" && s != StatusBanned {
+						print(r.String(statusLabels[s]))
+					}
+				}
+				print(r.String(locked))
+			}
+			`,
+		},
+	}.generate(t, TimeNow, "-l=en")
+	for _, res := range []RunResult{resLint, resGenerate} {
+		require.NoError(t, res.Err)
+		require.Zero(t, res.ExitCode)
+		texts := make(map[string]codeparse.Text)
+		for text := range res.Scan.Texts.SeqRead() {
+			texts[text.TIK.Raw] = text
+		}
+		require.Len(t, texts, 3)
+		require.Equal(t, []string{"main.go:12"}, texts["Active"].Locations())
+		require.Equal(t, []string{"Label of accounts in good standing."},
+			texts["Active"].Comments)
+		require.Equal(t, []string{"main.go:13"}, texts["Banned"].Locations())
+		require.Equal(t, []string{"main.go:9", "main.go:22"}, texts["Locked"].Locations())
+		require.Equal(t, []string{"Shown when the account is locked."},
+			texts["Locked"].Comments)
+		require.Equal(t, int64(1), res.Scan.StringCalls.Load())
+	}
+}
+
+// TestGenerateContext verifies that calls on the Reader carried by a context
+// are recognized as call sites.
+func TestGenerateContext(t *testing.T) {
+	dir, resLint, resGenerate := Setup{
+		InitGoMod: true, InitBundle: true,
+		FilesAfterInit: map[string]string{
+			"main.go": `
+			package main
+			import (
+				"context"
+				"os"
+				"tstmod/tokibundle"
+			)
+			func greet(ctx context.Context) string {
+				// Greeting on the home page.
+				return tokibundle.FromContext(ctx).String("Hello {text}!", "Alice")
+			}
+			func main() {
+				ctx := tokibundle.WithReader(context.Background(), tokibundle.Default())
+				print(greet(ctx))
+				_, _ = tokibundle.FromContext(context.Background()).Write(os.Stdout, "Bye")
+			}
+			`,
+		},
+	}.generate(t, TimeNow, "-l=en")
+	for _, res := range []RunResult{resLint, resGenerate} {
+		require.NoError(t, res.Err)
+		require.Zero(t, res.ExitCode)
+		texts := make(map[string]codeparse.Text)
+		for text := range res.Scan.Texts.SeqRead() {
+			texts[text.TIK.Raw] = text
+		}
+		require.Len(t, texts, 2)
+		require.Equal(t, []string{"main.go:9"}, texts["Hello {text}!"].Locations())
+		require.Equal(t, []string{"Greeting on the home page."},
+			texts["Hello {text}!"].Comments)
+		require.Equal(t, []string{"main.go:14"}, texts["Bye"].Locations())
+		require.Equal(t, int64(1), res.Scan.StringCalls.Load())
+		require.Equal(t, int64(1), res.Scan.WriteCalls.Load())
+	}
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = osEnv(t)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.Equal(t, "Hello Alice!Bye", string(out))
+}
+
+// TestGenerateAppend verifies that Append renders into the given buffer
+// without allocating for literal and numeric messages.
+func TestGenerateAppend(t *testing.T) {
+	dir, resLint, resGenerate := Setup{
+		InitGoMod: true, InitBundle: true,
+		FilesAfterInit: map[string]string{
+			"main.go": `
+			package main
+			import (
+				"fmt"
+				"testing"
+				"tstmod/tokibundle"
+			)
+			// noop is called indirectly to measure the allocation of the
+			// variadic arguments escaping, which happens at the call site.
+			var noop = func(b []byte, args ...any) []byte { return b }
+			func main() {
+				r := tokibundle.Default()
+				buf := make([]byte, 0, 64)
+				buf = r.Append(buf, "Saved")
+				buf = append(buf, ' ')
+				buf = r.Append(buf, "Found {integer} files: {number}", 42, 1.5)
+				fmt.Println(string(buf))
+				fmt.Println(testing.AllocsPerRun(100, func() {
+					buf = r.Append(buf[:0], "Saved")
+				}))
+				args := testing.AllocsPerRun(100, func() {
+					buf = noop(buf[:0], 1024, 0.25)
+				})
+				fmt.Println(testing.AllocsPerRun(100, func() {
+					buf = r.Append(buf[:0], "Found {integer} files: {number}", 1024, 0.25)
+				}) - args)
+			}
+			`,
+		},
+	}.generate(t, TimeNow, "-l=en")
+	for _, res := range []RunResult{resLint, resGenerate} {
+		require.NoError(t, res.Err)
+		require.Zero(t, res.ExitCode)
+		require.Equal(t, 2, res.Scan.Texts.Len())
+		require.Equal(t, int64(4), res.Scan.AppendCalls.Load())
+		require.Zero(t, res.Scan.StringCalls.Load())
+	}
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = osEnv(t)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.Equal(t, "Saved Found 42 files: 1.5\n0\n0\n", string(out))
+}
+
+// TestGenerateInterpreter verifies that catalogs loaded with LoadCatalog
+// are validated against the bundle and interpreted at runtime.
+func TestGenerateInterpreter(t *testing.T) {
+	// LoadCatalog only exists once the bundle was generated with -interpreter.
+	dir := t.TempDir()
+	initGoMod(t, dir, ModName)
+	// The interpreter requires the ICU tokenizer, use the version Toki uses.
+	version, err := exec.Command("go", "list", "-m", "-f", "{{.Version}}",
+		"github.com/romshark/icumsg").Output()
+	require.NoError(t, err)
+	cmd := exec.Command("go", "get",
+		"github.com/romshark/icumsg@"+strings.TrimSpace(string(version)))
+	cmd.Dir = dir
+	cmd.Env = osEnv(t)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	generate := func(t *testing.T, args ...string) app.Result {
+		t.Helper()
+		var res app.Result
+		runInDir(t, dir, func() {
+			res, _ = app.Run(append([]string{"toki", "generate"}, args...),
+				osEnv(t), io.Discard, io.Discard, TimeNow)
+		})
+		return res
+	}
+	require.NoError(t, generate(t, "-l=en", "-interpreter").Err)
+
+	writeFiles(t, dir, map[string]string{"main.go": `
+		package main
+		import (
+			"fmt"
+			"os"
+			"tstmod/tokibundle"
+		)
+		func main() {
+			f, err := os.Open(os.Args[1])
+			if err != nil {
+				panic(err)
+			}
+			r, err := tokibundle.LoadCatalog(f)
+			if err != nil {
+				fmt.Print(err)
+				return
+			}
+			fmt.Println(r.Locale())
+			fmt.Println(r.String("Hi {text}, {# messages}", "Bob", 1))
+			fmt.Println(r.String("Hi {text}, {# messages}", "Bob", 2))
+			fmt.Println(r.String("Took {integer} seconds", 12))
+			fmt.Println(r.String("Save changes"))
+		}
+	`})
+	resGenerate := generate(t, "-t=de", "-interpreter")
+	require.NoError(t, resGenerate.Err)
+	require.FileExists(t, filepath.Join(dir, "tokibundle", app.InterpreterFileGo))
+
+	ids := make(map[string]string)
+	for text := range resGenerate.Scan.Texts.SeqRead() {
+		ids[text.TIK.Raw] = text.IDHash
+	}
+	idHi, idTook := ids["Hi {text}, {# messages}"], ids["Took {integer} seconds"]
+
+	cmd = exec.Command("go", "build", "-o", "app", ".")
+	cmd.Dir = dir
+	cmd.Env = osEnv(t)
+	out, err = cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	run := func(t *testing.T, arb string) string {
+		t.Helper()
+		path := filepath.Join(dir, "update.arb")
+		require.NoError(t, os.WriteFile(path, []byte(arb), 0o644))
+		out, err := exec.Command(filepath.Join(dir, "app"), path).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return string(out)
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		out := run(t, fmt.Sprintf(`{
+			"@@locale": "de",
+			%q: "Hallo {var0}, {var1, plural, one {# Nachricht} other {# Nachrichten}}",
+			%q: "Dauerte {var0, number, integer} Sekunden"
+		}`, idHi, idTook))
+		// The missing message falls back to the default locale.
+		require.Equal(t, "de\n"+
+			"Hallo Bob, 1 Nachricht\n"+
+			"Hallo Bob, 2 Nachrichten\n"+
+			"Dauerte 12 Sekunden\n"+
+			"Save changes\n", out)
+	})
+
+	t.Run("unknown_locale", func(t *testing.T) {
+		out := run(t, `{"@@locale": "fr"}`)
+		require.Equal(t, `catalog locale not in bundle: "fr"`, out)
+	})
+
+	t.Run("unknown_message", func(t *testing.T) {
+		out := run(t, `{"@@locale": "de", "unknown": "Unbekannt"}`)
+		require.Equal(t, `invalid catalog message "unknown": not in bundle`, out)
+	})
+
+	t.Run("unknown_argument", func(t *testing.T) {
+		out := run(t, fmt.Sprintf(`{"@@locale": "de", %q: "Dauerte {var1}"}`, idTook))
+		require.Equal(t, fmt.Sprintf(
+			`invalid catalog message %q: unknown argument: "var1"`, idTook), out)
+	})
+
+	t.Run("unsupported_argument_usage", func(t *testing.T) {
+		out := run(t, fmt.Sprintf(
+			`{"@@locale": "de", %q: "{var0, select, other {Dauerte}}"}`, idTook))
+		require.Equal(t, fmt.Sprintf(
+			`invalid catalog message %q: argument "var0" can't be used as select argument`,
+			idTook), out)
+	})
+
+	// Without -interpreter the file is removed again.
+	require.NoError(t, generate(t, "-t=de").Err)
+	require.NoFileExists(t, filepath.Join(dir, "tokibundle", app.InterpreterFileGo))
+}
+
+// TestGenerateFallbacks verifies that missing translations fall back
+// to the CLDR parent locales or the configured fallback locales.
+func TestGenerateFallbacks(t *testing.T) {
+	dir, _, resGenerate := Setup{
+		InitGoMod: true, InitBundle: true,
+		FilesAfterInit: map[string]string{
+			"main.go": `
+			package main
+			import (
+				"fmt"
+				"golang.org/x/text/language"
+				"tstmod/tokibundle"
+			)
+			func main() {
+				r, _ := tokibundle.Match(language.MustParse("de-CH"))
+				fmt.Println(r.Locale(), r.Fallbacks())
+				fmt.Println(r.String("Save"))
+				fmt.Println(r.String("Cancel"))
+			}
+			`,
+		},
+	}.generate(t, TimeNow, "-t=de", "-t=de-CH")
+	require.NoError(t, resGenerate.Err)
+
+	var idSave string
+	for text := range resGenerate.Scan.Texts.SeqRead() {
+		if text.TIK.Raw == "Save" {
+			idSave = text.IDHash
+		}
+	}
+	pathDE := filepath.Join(dir, "tokibundle", "catalog_de.arb")
+	de := readARBFile(t, pathDE)
+	msg := de.Messages[idSave]
+	msg.ICUMessage = "Speichern"
+	de.Messages[idSave] = msg
+	writeARBFile(t, pathDE, de)
+
+	run := func(t *testing.T, args ...string) string {
+		t.Helper()
+		runInDir(t, dir, func() {
+			res, _ := app.Run(append([]string{"toki", "generate"}, args...),
+				osEnv(t), io.Discard, io.Discard, TimeNow)
+			require.NoError(t, res.Err)
+		})
+		cmd := exec.Command("go", "run", ".")
+		cmd.Dir = dir
+		cmd.Env = osEnv(t)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return string(out)
+	}
+
+	// de-CH falls back to its parent de and then to the default locale.
+	require.Equal(t, "de-CH [de en]\nSpeichern\nCancel\n", run(t))
+
+	// Configured fallbacks override the parent locales.
+	require.Equal(t, "de-CH [en]\nSave\nCancel\n", run(t, "-fallback=de-CH=en"))
+}
+
+// TestGenerateMiddleware verifies that the HTTP middleware of the bundle
+// chooses the reader from the first locale source matching a bundle locale.
+func TestGenerateMiddleware(t *testing.T) {
+	dir, _, resGenerate := Setup{
+		InitGoMod: true, InitBundle: true,
+		FilesAfterInit: map[string]string{
+			"main.go": `
+			package main
+			import (
+				"fmt"
+				"net/http"
+				"net/http/httptest"
+				"strings"
+				"golang.org/x/text/language"
+				"tstmod/tokibundle"
+			)
+			func main() {
+				custom := func(r *http.Request) []language.Tag {
+					if l := r.Header.Get("X-Locale"); l != "" {
+						return []language.Tag{language.Make(l)}
+					}
+					return nil
+				}
+				h := tokibundle.Middleware(tokibundle.MiddlewareConfig{
+					Sources: []tokibundle.LocaleSource{
+						tokibundle.LocaleFromFunc(custom, "X-Locale"),
+						{}, // The zero value is skipped.
+						tokibundle.LocaleFromQuery("lang"),
+						tokibundle.LocaleFromCookie("lang"),
+						tokibundle.LocaleFromAcceptLanguage(),
+					},
+					SetHeaders: true,
+				}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					fmt.Fprint(w, tokibundle.FromContext(r.Context()).Locale())
+				}))
+				for _, req := range []struct{ target, header, cookie, accept string }{
+					{"/?lang=en", "de", "", ""},
+					{"/?lang=de", "", "en", ""},
+					{"/?lang=xx", "", "en", "de"},
+					{"/", "", "", "fr, de;q=0.8"},
+					{"/", "", "", "fr"},
+				} {
+					r := httptest.NewRequest("GET", req.target, nil)
+					r.Header.Set("X-Locale", req.header)
+					r.Header.Set("Accept-Language", req.accept)
+					if req.cookie != "" {
+						r.AddCookie(&http.Cookie{Name: "lang", Value: req.cookie})
+					}
+					w := httptest.NewRecorder()
+					h.ServeHTTP(w, r)
+					fmt.Println(w.Body.String(), w.Header().Get("Content-Language"),
+						strings.Join(w.Header().Values("Vary"), ","))
+				}
+			}
+			`,
+		},
+	}.generate(t, TimeNow, "-l=en", "-t=de")
+	require.NoError(t, resGenerate.Err)
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = osEnv(t)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	const vary = "X-Locale,Cookie,Accept-Language"
+	require.Equal(t, "de de "+vary+"\n"+
+		"de de "+vary+"\n"+
+		"en en "+vary+"\n"+
+		"de de "+vary+"\n"+
+		"en en "+vary+"\n", string(out))
+}
+
+// TestGenerateBuilds verifies that texts are collected from all build
+// configurations passed with -build and that texts found in only some
+// of them are reported.
+func TestGenerateBuilds(t *testing.T) {
+	_, resLint, resGenerate := Setup{
+		InitGoMod: true, InitBundle: true,
+		FilesAfterInit: map[string]string{
+			"main.go": `
+			package main
+			import "tstmod/tokibundle"
+			func main() {
+				print(tokibundle.Default().String("Hello"), label())
+			}
+			`,
+			"label_linux.go": `
+			package main
+			import "tstmod/tokibundle"
+			func label() string { return tokibundle.Default().String("Linux") }
+			`,
+			"label_windows.go": `
+			package main
+			import "tstmod/tokibundle"
+			func label() string { return tokibundle.Default().String("Windows") }
+			`,
+			"integration.go": `
+			//go:build integration
+			package main
+			import "tstmod/tokibundle"
+			func init() { print(tokibundle.Default().String("Integration")) }
+			`,
+		},
+	}.generate(t, TimeNow, "-l=en",
+		"-build=linux/amd64", "-build=windows/amd64:integration")
+	for _, res := range []RunResult{resLint, resGenerate} {
+		require.NoError(t, res.Err)
+		require.Zero(t, res.ExitCode)
+		require.Equal(t,
+			[]string{"linux/amd64", "windows/amd64:integration"}, res.Scan.Builds)
+		texts := make(map[string]codeparse.Text)
+		for text := range res.Scan.Texts.SeqRead() {
+			texts[text.TIK.Raw] = text
+		}
+		require.Len(t, texts, 4)
+		require.Equal(t, []string{"main.go:4"}, texts["Hello"].Locations())
+		require.Equal(t, int64(4), res.Scan.StringCalls.Load())
+
+		partial := make(map[string][]string)
+		for _, text := range res.PartialTexts {
+			partial[text.TIK.Raw] = text.Builds
+		}
+		require.Equal(t, map[string][]string{
+			"Linux":       {"linux/amd64"},
+			"Windows":     {"windows/amd64:integration"},
+			"Integration": {"windows/amd64:integration"},
+		}, partial)
+	}
+}
+
+// TestLintFormat verifies that `toki lint -format` writes all findings
+// in machine-readable formats to stdout.
+func TestLintFormat(t *testing.T) {
+	dir := t.TempDir()
+	initGoMod(t, dir, "tstmod")
+	writeFiles(t, dir, map[string]string{
+		"main.go": `
+			package main
+			import "tstmod/tokibundle"
+			func main() {
+				r := tokibundle.Default()
+				print(r.String("There are {# errors}", 2))
+			}
+		`,
+	})
+	run := func(t *testing.T, args ...string) (stdout string, exitCode int) {
+		t.Helper()
+		var b bytes.Buffer
+		runInDir(t, dir, func() {
+			_, exitCode = app.Run(args, osEnv(t), io.Discard, &b, TimeNow)
+		})
+		return b.String(), exitCode
+	}
+	_, exitCode := run(t, "toki", "generate", "-l=en", "-t=de")
+	require.Zero(t, exitCode)
+	_, exitCode = run(t, "toki", "generate")
+	require.Zero(t, exitCode)
+
+	// German requires plural option "one".
+	pathDE := filepath.Join(dir, "tokibundle", "catalog_de.arb")
+	catalogDE := readARBFile(t, pathDE)
+	require.Len(t, catalogDE.Messages, 1)
+	var id string
+	for id = range catalogDE.Messages {
+		msg := catalogDE.Messages[id]
+		msg.ICUMessage = "Es gibt {var0, plural, other {# Fehler}}"
+		catalogDE.Messages[id] = msg
+	}
+	writeARBFile(t, pathDE, catalogDE)
+	dataDE, err := os.ReadFile(pathDE)
+	require.NoError(t, err)
+	lineDE := 0
+	for i, l := range strings.Split(string(dataDE), "\n") {
+		if strings.Contains(l, `"`+id+`"`) {
+			lineDE = i + 1
+			break
+		}
+	}
+	require.NotZero(t, lineDE)
+
+	writeFiles(t, dir, map[string]string{
+		"bad.go": `
+			package main
+			import "tstmod/tokibundle"
+			func bad(s string) string { return tokibundle.Default().String(tokibundle.TIK(s)) }
+		`,
+	})
+
+	t.Run("github", func(t *testing.T) {
+		stdout, exitCode := run(t, "toki", "lint", "-format=github")
+		require.Equal(t, 1, exitCode)
+		require.Equal(t, "::error file=bad.go,line=3,col=67,title=toki/source-error"+
+			"::TIK: not a constant\n"+
+			fmt.Sprintf("::warning file=tokibundle/catalog_de.arb,line=%d,"+
+				"title=toki/icu-incomplete::%s: Argument \"var0\" is missing options [one]\n",
+				lineDE, id)+
+			fmt.Sprintf("::warning file=tokibundle/catalog_en.arb,line=%d,"+
+				"title=toki/icu-incomplete::%s: Argument \"var0\" is missing options [one]\n",
+				lineDE, id), stdout)
+	})
+
+	t.Run("checkstyle", func(t *testing.T) {
+		stdout, exitCode := run(t, "toki", "lint", "-format=checkstyle")
+		require.Equal(t, 1, exitCode)
+		require.Contains(t, stdout, `<file name="bad.go">`)
+		require.Contains(t, stdout, `source="toki/source-error"`)
+		require.Contains(t, stdout, `<file name="tokibundle/catalog_de.arb">`)
+		require.Contains(t, stdout, `source="toki/icu-incomplete"`)
+	})
+
+	t.Run("sarif", func(t *testing.T) {
+		stdout, exitCode := run(t, "toki", "lint", "-format=sarif")
+		require.Equal(t, 1, exitCode)
+		var log struct {
+			Runs []struct {
+				Results []struct {
+					RuleID string `json:"ruleId"`
+					Level  string `json:"level"`
+				} `json:"results"`
+			} `json:"runs"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(stdout), &log))
+		require.Len(t, log.Runs, 1)
+		results := log.Runs[0].Results
+		require.Len(t, results, 3)
+		require.Equal(t, "toki/source-error", results[0].RuleID)
+		require.Equal(t, "error", results[0].Level)
+		for _, r := range results[1:] {
+			require.Equal(t, "toki/icu-incomplete", r.RuleID)
+			require.Equal(t, "warning", r.Level)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, exitCode := run(t, "toki", "lint", "-format=html")
+		require.Equal(t, 2, exitCode)
+	})
+
+	t.Run("arb invalid", func(t *testing.T) {
+		writeFiles(t, dir, map[string]string{"tokibundle/catalog_de.arb": "{}"})
+		stdout, exitCode := run(t, "toki", "lint", "-format=github")
+		require.Equal(t, 1, exitCode)
+		require.Equal(t, "::error file=tokibundle/catalog_de.arb,title=toki/arb-invalid"+
+			"::missing required @@locale\n", stdout)
+	})
+}
+
+// TestGenerateCheck verifies that `toki generate -check` detects
+// an out of date bundle without writing any files.
+// TestGenerateWatch verifies that watch mode regenerates the bundle whenever
+// a source file or a catalog is changed.
+func TestGenerateWatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("interrupting the watcher requires sending os.Interrupt")
+	}
+
+	dir := t.TempDir()
+	initGoMod(t, dir, "tstmod")
+	runInDir(t, dir, func() {
+		args := []string{"toki", "generate", "-l=en", "-t=de"}
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.NoError(t, result.Err)
+		require.Zero(t, exitCode)
+	})
+
+	writeMain := func(t *testing.T, texts ...string) {
+		t.Helper()
+		var b strings.Builder
+		for _, text := range texts {
+			fmt.Fprintf(&b, "print(tokibundle.Default().String(%q))\n", text)
+		}
+		writeFiles(t, dir, map[string]string{
+			"main.go": "package main\n" +
+				"import \"tstmod/tokibundle\"\n" +
+				"func main() {\n" + b.String() + "}\n",
+		})
+	}
+	writeMain(t, "Hello")
+
+	pathEN := filepath.Join(dir, "tokibundle", "catalog_en.arb")
+	pathDE := filepath.Join(dir, "tokibundle", "catalog_de.arb")
+	pathGoDE := filepath.Join(dir, "tokibundle", "catalog_de_gen.go")
+	contains := func(path, s string) func() bool {
+		return func() bool {
+			data, err := os.ReadFile(path)
+			return err == nil && strings.Contains(string(data), s)
+		}
+	}
+
+	runInDir(t, dir, func() {
+		done := make(chan app.Result, 1)
+		go func() {
+			args := []string{"toki", "generate", "-watch"}
+			result, _ := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+			done <- result
+		}()
+
+		require.Eventually(t, contains(pathEN, `"Hello"`), time.Minute, 50*time.Millisecond)
+
+		// A changed source file triggers regeneration.
+		writeMain(t, "Hello", "Bye")
+		require.Eventually(t, contains(pathEN, `"Bye"`), time.Minute, 50*time.Millisecond)
+
+		// A hand-edited catalog triggers regeneration.
+		catalogEN := readARBFile(t, pathEN)
+		catalogDE := readARBFile(t, pathDE)
+		for id, msg := range catalogEN.Messages {
+			if msg.ICUMessage == "Hello" {
+				msg.ICUMessage = "Hallo"
+				catalogDE.Messages[id] = msg
+			}
+		}
+		writeARBFile(t, pathDE, catalogDE)
+		require.Eventually(t, contains(pathGoDE, `"Hallo"`), time.Minute, 50*time.Millisecond)
+
+		p, err := os.FindProcess(os.Getpid())
+		require.NoError(t, err)
+		require.NoError(t, p.Signal(os.Interrupt))
+		select {
+		case result := <-done:
+			require.NoError(t, result.Err)
+		case <-time.After(time.Minute):
+			t.Fatal("watcher didn't stop")
+		}
+	})
+}
+
+func TestGenerateCheck(t *testing.T) {
+	dir := t.TempDir()
+	initGoMod(t, dir, "tstmod")
+	_ = initBundle(t, dir, language.English, "tokibundle", io.Discard, io.Discard)
+
+	runInDir(t, dir, func() {
+		var stdout bytes.Buffer
+		args := []string{"toki", "generate", "-check"}
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, &stdout, TimeNow)
+		require.NoError(t, result.Err)
+		require.Zero(t, exitCode)
+		require.Empty(t, result.StaleFiles)
+		require.Zero(t, stdout.String())
+	})
+
+	writeFiles(t, dir, map[string]string{
+		"main.go": `
+			package main
+			import "tstmod/tokibundle"
+			func main() { print(tokibundle.Default().String("new text")) }
+		`,
+	})
+
+	ss := snapshotFiles(t, dir)
+	runInDir(t, dir, func() {
+		var stdout bytes.Buffer
+		args := []string{"toki", "generate", "-check"}
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, &stdout, TimeNow)
+		require.ErrorIs(t, result.Err, app.ErrBundleStale)
+		require.Equal(t, 1, exitCode)
+		require.Equal(t, []string{
+			"tokibundle/catalog_en.arb",
+			"tokibundle/bundle_gen.go",
+			"tokibundle/catalog_en_gen.go",
+		}, result.StaleFiles)
+		require.Contains(t, stdout.String(), "--- a/tokibundle/catalog_en.arb\n"+
+			"+++ b/tokibundle/catalog_en.arb\n")
+		require.Contains(t, stdout.String(), `+	"msge3736d0306ba5df6": "new text",`)
+	})
+	ss.RequireUnchanged(t, dir)
+
+	runInDir(t, dir, func() {
+		args := []string{"toki", "generate"}
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.NoError(t, result.Err)
+		require.Zero(t, exitCode)
+
+		args = []string{"toki", "generate", "-check"}
+		result, exitCode = app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.NoError(t, result.Err)
+		require.Zero(t, exitCode)
+	})
+}
+
+// TestGenerateFuzzyCarryOver verifies that translations of an edited TIK
+// are carried over to the new message and marked as fuzzy.
+func TestGenerateFuzzyCarryOver(t *testing.T) {
+	dir := t.TempDir()
+	initGoMod(t, dir, "tstmod")
+	writeFiles(t, dir, map[string]string{
+		"main.go": `
+			package main
+			import "tstmod/tokibundle"
+			func main() {
+				r := tokibundle.Default()
+				print(r.String("Recieve {# messages}", 2))
+				print(r.String("unrelated"))
+			}
+		`,
+	})
+
+	runInDir(t, dir, func() {
+		args := []string{"toki", "generate", "-l=en", "-t=de"}
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.NoError(t, result.Err)
+		require.Zero(t, exitCode)
+		args = []string{"toki", "generate"}
+		result, exitCode = app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.NoError(t, result.Err)
+		require.Zero(t, exitCode)
+	})
+
+	// Translate all messages.
+	pathDE := filepath.Join(dir, "tokibundle", "catalog_de.arb")
+	catalogDE := readARBFile(t, pathDE)
+	require.Len(t, catalogDE.Messages, 2)
+	for id, msg := range catalogDE.Messages {
+		msg.ICUMessage = "übersetzt {var0, plural, other {#}}"
+		if len(msg.Placeholders) == 0 {
+			msg.ICUMessage = "übersetzt"
+		}
+		catalogDE.Messages[id] = msg
+	}
+	writeARBFile(t, pathDE, catalogDE)
+
+	// Fix the typo and limit the length.
+	writeFiles(t, dir, map[string]string{
+		"main.go": `
+			package main
+			import "tstmod/tokibundle"
+			func main() {
+				r := tokibundle.Default()
+				//toki:maxlen 40
+				print(r.String("Receive {# messages}", 2))
+				print(r.String("unrelated"))
+			}
+		`,
+	})
+
+	runInDir(t, dir, func() {
+		args := []string{"toki", "generate"}
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.NoError(t, result.Err)
+		require.Zero(t, exitCode)
+		require.Len(t, result.NewTexts, 1)
+		require.Len(t, result.RemovedTexts, 1)
+		require.Len(t, result.FuzzyTexts, 1)
+		require.Equal(t, "Receive {# messages}", result.FuzzyTexts[0].TIK.Raw)
+
+		catalogDE := readARBFile(t, pathDE)
+		require.Len(t, catalogDE.Messages, 2)
+		msg := catalogDE.Messages[result.FuzzyTexts[0].IDHash]
+		require.Equal(t, "übersetzt {var0, plural, other {#}}", msg.ICUMessage)
+		require.Equal(t, "Recieve {# messages}", msg.CustomAttributes["x-toki-fuzzy"])
+		require.Equal(t, float64(40), msg.CustomAttributes["x-toki-maxlen"])
+		require.Equal(t, "main.go:6", msg.CustomAttributes["x-toki-position"])
+
+		// Fuzzy messages are incomplete until reviewed.
+		args = []string{"toki", "lint", "-require-complete"}
+		result, exitCode = app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.ErrorIs(t, result.Err, app.ErrBundleIncomplete)
+		require.Equal(t, 1, exitCode)
+	})
+}
+
+func TestGenerateObsoleteArchive(t *testing.T) {
+	dir := t.TempDir()
+	initGoMod(t, dir, "tstmod")
+	mainGo := func(texts ...string) map[string]string {
+		var b strings.Builder
+		for _, s := range texts {
+			fmt.Fprintf(&b, "print(r.String(%q))\n", s)
+		}
+		return map[string]string{"main.go": `
+			package main
+			import "tstmod/tokibundle"
+			func main() {
+				r := tokibundle.Default()
+				` + b.String() + `
+			}
+		`}
+	}
+	run := func(now time.Time, args ...string) app.Result {
+		t.Helper()
+		var result app.Result
+		runInDir(t, dir, func() {
+			var exitCode int
+			result, exitCode = app.Run(args, osEnv(t), io.Discard, io.Discard, now)
+			require.NoError(t, result.Err)
+			require.Zero(t, exitCode)
+		})
+		return result
+	}
+
+	writeFiles(t, dir, mainGo("kept", "removed"))
+	run(TimeNow, "toki", "generate", "-l=en", "-t=de")
+	run(TimeNow, "toki", "generate")
+
+	// Translate all messages.
+	pathDE := filepath.Join(dir, "tokibundle", "catalog_de.arb")
+	catalogDE := readARBFile(t, pathDE)
+	require.Len(t, catalogDE.Messages, 2)
+	for id, msg := range catalogDE.Messages {
+		msg.ICUMessage = "übersetzt"
+		catalogDE.Messages[id] = msg
+	}
+	writeARBFile(t, pathDE, catalogDE)
+
+	pathObsoleteEN := filepath.Join(dir, "tokibundle", "catalog_en.obsolete.arb")
+	pathObsoleteDE := filepath.Join(dir, "tokibundle", "catalog_de.obsolete.arb")
+
+	// Remove a text, it's archived in all catalogs.
+	writeFiles(t, dir, mainGo("kept"))
+	result := run(TimeNow, "toki", "generate")
+	require.Len(t, result.RemovedTexts, 1)
+	removedID := result.RemovedTexts[0].IDHash
+
+	require.NotContains(t, readARBFile(t, pathDE).Messages, removedID)
+	obsoleteEN := readARBFile(t, pathObsoleteEN)
+	require.Len(t, obsoleteEN.Messages, 1)
+	require.Equal(t, "removed", obsoleteEN.Messages[removedID].ICUMessage)
+	require.Equal(t, "main.go:6",
+		obsoleteEN.Messages[removedID].CustomAttributes["x-toki-position"])
+	obsoleteDE := readARBFile(t, pathObsoleteDE)
+	require.Len(t, obsoleteDE.Messages, 1)
+	require.Equal(t, "übersetzt", obsoleteDE.Messages[removedID].ICUMessage)
+	require.Equal(t, TimeNow.Format(time.RFC3339),
+		obsoleteDE.Messages[removedID].CustomAttributes["x-toki-obsolete-since"])
+
+	// Reintroduce the text, its translation is restored.
+	writeFiles(t, dir, mainGo("kept", "removed"))
+	result = run(TimeNow, "toki", "generate")
+	require.Empty(t, result.NewTexts)
+	require.Len(t, result.RestoredTexts, 1)
+	require.Equal(t, removedID, result.RestoredTexts[0].IDHash)
+
+	msgDE := readARBFile(t, pathDE).Messages[removedID]
+	require.Equal(t, "übersetzt", msgDE.ICUMessage)
+	require.NotContains(t, msgDE.CustomAttributes, "x-toki-obsolete-since")
+	require.NoFileExists(t, pathObsoleteEN)
+	require.NoFileExists(t, pathObsoleteDE)
+
+	// Prune only archived messages older than the given duration.
+	writeFiles(t, dir, mainGo("kept"))
+	run(TimeNow, "toki", "generate")
+
+	run(TimeNow.Add(12*time.Hour), "toki", "prune", "-older-than=1d")
+	require.Len(t, readARBFile(t, pathObsoleteEN).Messages, 1)
+	require.Len(t, readARBFile(t, pathObsoleteDE).Messages, 1)
+
+	run(TimeNow.Add(48*time.Hour), "toki", "prune", "-older-than=1d")
+	require.NoFileExists(t, pathObsoleteEN)
+	require.NoFileExists(t, pathObsoleteDE)
+}
+
+// TestPruneModPath verifies that prune finds the bundle and the configuration
+// of the module at -m.
+func TestPruneModPath(t *testing.T) {
+	dir := t.TempDir()
+	modDir := filepath.Join(dir, "sub")
+	require.NoError(t, os.Mkdir(modDir, 0o755))
+	initGoMod(t, modDir, "tstmod")
+	mainGo := func(texts ...string) map[string]string {
+		var b strings.Builder
+		for _, s := range texts {
+			fmt.Fprintf(&b, "print(r.String(%q))\n", s)
+		}
+		return map[string]string{
+			".toki.yml": "bundle: i18n",
+			"main.go": `
+				package main
+				import "tstmod/i18n"
+				func main() {
+					r := i18n.Default()
+					` + b.String() + `
+				}
+			`,
+		}
+	}
+	run := func(dir string, now time.Time, args ...string) {
+		t.Helper()
+		runInDir(t, dir, func() {
+			result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, now)
+			require.NoError(t, result.Err)
+			require.Zero(t, exitCode)
+		})
+	}
+
+	writeFiles(t, modDir, mainGo("kept", "removed"))
+	run(modDir, TimeNow, "toki", "generate", "-l=en")
+	writeFiles(t, modDir, mainGo("kept"))
+	run(modDir, TimeNow, "toki", "generate")
+	pathObsolete := filepath.Join(modDir, "i18n", "catalog_en.obsolete.arb")
+	require.Len(t, readARBFile(t, pathObsolete).Messages, 1)
+
+	run(dir, TimeNow.Add(48*time.Hour), "toki", "prune", "-m=sub", "-older-than=1d")
+	require.NoFileExists(t, pathObsolete)
+}
+
+func TestPruneErr(t *testing.T) {
+	dir := t.TempDir()
+	runInDir(t, dir, func() {
+		args := []string{"toki", "prune"}
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.ErrorIs(t, result.Err, app.ErrInvalidCLIArgs)
+		require.Equal(t, 2, exitCode)
+
+		args = []string{"toki", "prune", "-older-than=-3d"}
+		result, exitCode = app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+		require.ErrorIs(t, result.Err, app.ErrInvalidCLIArgs)
+		require.Equal(t, 2, exitCode)
+	})
+}
+
+func TestStats(t *testing.T) {
+	dir := t.TempDir()
+	initGoMod(t, dir, "tstmod")
 	writeFiles(t, dir, map[string]string{
-		".tokidomain.yml": customDomain,
+		"main.go": `
+			package main
+			import (
+				"tstmod/store"
+				"tstmod/tokibundle"
+			)
+			func main() {
+				r := tokibundle.Default()
+				print(r.String("hello"))
+				print(r.String("bye"))
+				print(store.Title(r))
+			}
+		`,
+		"store/store.go": `
+			package store
+			import "tstmod/tokibundle"
+			func Title(r tokibundle.Reader) string { return r.String("store title") }
+		`,
+		"store/.tokidomain.yml": "name: store",
 	})
 
 	runInDir(t, dir, func() {
-		args := []string{"toki", "generate", "-l=en"}
-		result, exitCode := app.Run(args, osEnv(), io.Discard, io.Discard, TimeNow)
+		args := []string{"toki", "generate", "-l=en", "-t=de"}
+		result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
 		require.NoError(t, result.Err)
 		require.Zero(t, exitCode)
 	})
 
-	// Verify .tokidomain.yml was not overwritten.
-	content, err := os.ReadFile(filepath.Join(dir, ".tokidomain.yml"))
-	require.NoError(t, err)
-	require.Equal(t, customDomain, string(content))
+	// Translate "hello" only.
+	pathDE := filepath.Join(dir, "tokibundle", "catalog_de.arb")
+	catalogEN := readARBFile(t, filepath.Join(dir, "tokibundle", "catalog_en.arb"))
+	catalogDE := readARBFile(t, pathDE)
+	for id, msg := range catalogEN.Messages {
+		if msg.ICUMessage == "hello" {
+			msg.ICUMessage = "hallo"
+			catalogDE.Messages[id] = msg
+		}
+	}
+	writeARBFile(t, pathDE, catalogDE)
+
+	stats := func(t *testing.T, format string) string {
+		t.Helper()
+		var stdout bytes.Buffer
+		runInDir(t, dir, func() {
+			args := []string{"toki", "stats", "-format=" + format}
+			result, exitCode := app.Run(args, osEnv(t), io.Discard, &stdout, TimeNow)
+			require.NoError(t, result.Err)
+			require.Zero(t, exitCode)
+		})
+		return stdout.String()
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var report app.StatsReport
+		require.NoError(t, json.Unmarshal([]byte(stats(t, "json")), &report))
+
+		counts := func(messages, empty int) app.StatsCounts {
+			return app.StatsCounts{Messages: messages, Empty: empty}
+		}
+		require.Equal(t, []app.StatsLocale{
+			{Locale: "en", StatsCounts: counts(3, 0), Completeness: 1},
+			{Locale: "de", StatsCounts: counts(3, 2), Completeness: 1.0 / 3},
+		}, report.Locales)
+		require.Equal(t, []app.StatsGroup{
+			{Name: "tstmod", Locales: []app.StatsLocale{
+				{Locale: "en", StatsCounts: counts(2, 0), Completeness: 1},
+				{Locale: "de", StatsCounts: counts(2, 1), Completeness: 0.5},
+			}},
+			{Name: "tstmod/store", Locales: []app.StatsLocale{
+				{Locale: "en", StatsCounts: counts(1, 0), Completeness: 1},
+				{Locale: "de", StatsCounts: counts(1, 1), Completeness: 0},
+			}},
+		}, report.Domains)
+		require.Equal(t, []app.StatsGroup{
+			{Name: "tstmod", Locales: []app.StatsLocale{
+				{Locale: "en", StatsCounts: counts(2, 0), Completeness: 1},
+				{Locale: "de", StatsCounts: counts(2, 1), Completeness: 0.5},
+			}},
+			{Name: "tstmod/store", Locales: []app.StatsLocale{
+				{Locale: "en", StatsCounts: counts(1, 0), Completeness: 1},
+				{Locale: "de", StatsCounts: counts(1, 1), Completeness: 0},
+			}},
+		}, report.Packages)
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		require.Equal(t, "## Locales\n\n"+
+			"| Locale | Messages | Incomplete | Empty | Invalid ICU | Completeness |\n"+
+			"| --- | ---: | ---: | ---: | ---: | ---: |\n"+
+			"| en | 3 | 0 | 0 | 0 | 100.00% |\n"+
+			"| de | 3 | 0 | 2 | 0 | 33.33% |\n"+
+			"\n## Domains\n\n"+
+			"| Domain | Locale | Messages | Incomplete | Empty | Invalid ICU | Completeness |\n"+
+			"| --- | --- | ---: | ---: | ---: | ---: | ---: |\n"+
+			"| tstmod | en | 2 | 0 | 0 | 0 | 100.00% |\n"+
+			"| tstmod | de | 2 | 0 | 1 | 0 | 50.00% |\n"+
+			"| tstmod/store | en | 1 | 0 | 0 | 0 | 100.00% |\n"+
+			"| tstmod/store | de | 1 | 0 | 1 | 0 | 0.00% |\n"+
+			"\n## Packages\n\n"+
+			"| Package | Locale | Messages | Incomplete | Empty | Invalid ICU | Completeness |\n"+
+			"| --- | --- | ---: | ---: | ---: | ---: | ---: |\n"+
+			"| tstmod | en | 2 | 0 | 0 | 0 | 100.00% |\n"+
+			"| tstmod | de | 2 | 0 | 1 | 0 | 50.00% |\n"+
+			"| tstmod/store | en | 1 | 0 | 0 | 0 | 100.00% |\n"+
+			"| tstmod/store | de | 1 | 0 | 1 | 0 | 0.00% |\n",
+			stats(t, "markdown"))
+	})
+
+	t.Run("text", func(t *testing.T) {
+		require.True(t, strings.HasPrefix(stats(t, "text"), "LOCALES:\n"+
+			"LOCALE  MESSAGES  INCOMPLETE  EMPTY  INVALID ICU  COMPLETENESS\n"+
+			"en      3         0           0      0            100.00%\n"+
+			"de      3         0           2      0            33.33%\n"))
+	})
 }
 
-// TestGenerateSameTIKDifferentDomains verifies that the same TIK string used in
-// different domains (including parent vs. sub-domain) does not cause a collision.
-func TestGenerateSameTIKDifferentDomains(t *testing.T) {
+// TestStatsMultipleBundles verifies that every bundle is reported separately,
+// that texts are collected from all build configurations passed with -build
+// and that domains with identical names in different directories aren't merged.
+func TestStatsMultipleBundles(t *testing.T) {
 	dir := t.TempDir()
 	initGoMod(t, dir, "tstmod")
 	writeFiles(t, dir, map[string]string{
-		".tokidomain.yml": "name: root\ndescription: \"\"",
+		".toki.yml": "locale: en\n" +
+			"bundles:\n" +
+			"  - path: webbundle\n" +
+			"  - path: mailbundle\n",
+	})
+
+	generate := func(t *testing.T) {
+		t.Helper()
+		runInDir(t, dir, func() {
+			args := []string{"toki", "generate",
+				"-build=linux/amd64", "-build=windows/amd64"}
+			result, exitCode := app.Run(args, osEnv(t), io.Discard, io.Discard, TimeNow)
+			require.NoError(t, result.Err)
+			require.Zero(t, exitCode)
+		})
+	}
+	generate(t)
+
+	writeFiles(t, dir, map[string]string{
 		"main.go": `
 			package main
-			import "fmt"
-			import "tstmod/tokibundle"
-			import "golang.org/x/text/language"
+			import (
+				"tstmod/a"
+				"tstmod/b"
+				"tstmod/mailbundle"
+				"tstmod/webbundle"
+			)
 			func main() {
-				r, _ := tokibundle.Match(language.English)
-				fmt.Println(r.String("hello world"))
+				print(webbundle.Default().String("Welcome"), a.Title(), b.Title())
+				print(mailbundle.Default().String("Confirm your email"))
 			}
 		`,
-		"sub/.tokidomain.yml": "name: sub\ndescription: \"\"",
-		"sub/sub.go": `
-			package sub
+		"main_windows.go": `
+			package main
+			import "tstmod/mailbundle"
+			func init() { print(mailbundle.Default().String("Windows only")) }
+		`,
+		"a/a.go": `
+			package a
+			import "tstmod/webbundle"
+			func Title() string { return webbundle.Default().String("A") }
+		`,
+		"a/.tokidomain.yml": "name: shop",
+		"b/b.go": `
+			package b
+			import "tstmod/webbundle"
+			func Title() string { return webbundle.Default().String("B") }
+		`,
+		"b/.tokidomain.yml": "name: shop",
+	})
+	generate(t)
+
+	stats := func(t *testing.T, args ...string) app.StatsReport {
+		t.Helper()
+		var stdout bytes.Buffer
+		runInDir(t, dir, func() {
+			args := append([]string{"toki", "stats", "-format=json"}, args...)
+			result, exitCode := app.Run(args, osEnv(t), io.Discard, &stdout, TimeNow)
+			require.NoError(t, result.Err)
+			require.Zero(t, exitCode)
+		})
+		var report app.StatsReport
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &report))
+		return report
+	}
+
+	counts := func(messages int) []app.StatsLocale {
+		return []app.StatsLocale{{
+			Locale:       "en",
+			StatsCounts:  app.StatsCounts{Messages: messages},
+			Completeness: 1,
+		}}
+	}
+
+	report := stats(t, "-build=linux/amd64", "-build=windows/amd64")
+	require.Empty(t, report.Locales)
+	require.Len(t, report.Bundles, 2)
+
+	web, mail := report.Bundles[0], report.Bundles[1]
+	require.Equal(t, "webbundle", web.Bundle)
+	require.Equal(t, counts(3), web.Locales)
+	require.Equal(t, []app.StatsGroup{
+		{Name: "tstmod", Locales: counts(1)},
+		{Name: "tstmod/shop (a)", Locales: counts(1)},
+		{Name: "tstmod/shop (b)", Locales: counts(1)},
+	}, web.Domains)
+
+	require.Equal(t, "mailbundle", mail.Bundle)
+	require.Equal(t, counts(2), mail.Locales)
+
+	// Only the linux build configuration.
+	report = stats(t, "-build=linux/amd64")
+	require.Equal(t, counts(1), report.Bundles[1].Locales)
+
+	// A single bundle.
+	report = stats(t, "-b=mailbundle", "-build=windows/amd64")
+	require.Empty(t, report.Bundles)
+	require.Equal(t, "mailbundle", report.Bundle)
+	require.Equal(t, counts(2), report.Locales)
+}
+
+func TestGenerateScanCache(t *testing.T) {
+	dir := t.TempDir()
+	initGoMod(t, dir, "tstmod")
+	writeFiles(t, dir, map[string]string{
+		"main.go": `
+			package main
+			import (
+				"tstmod/other"
+				"tstmod/store"
+				"tstmod/tokibundle"
+			)
+			func main() {
+				r := tokibundle.Default()
+				print(r.String("main"))
+				print(store.Title(r), other.Title(r))
+			}
+		`,
+		"store/store.go": `
+			package store
 			import "tstmod/tokibundle"
-			import "golang.org/x/text/language"
-			func Hello() string {
-				r, _ := tokibundle.Match(language.English)
-				return r.String("hello world")
+			func Title(r tokibundle.Reader) string { return r.String("store") }
+		`,
+		"other/other.go": `
+			package other
+			import "tstmod/tokibundle"
+			func Title(r tokibundle.Reader) string { return r.String("other") }
+		`,
+	})
+
+	generate := func(t *testing.T, env []string) app.Result {
+		t.Helper()
+		var result app.Result
+		runInDir(t, dir, func() {
+			var exitCode int
+			args := []string{"toki", "generate", "-l=en"}
+			result, exitCode = app.Run(args, env, io.Discard, io.Discard, TimeNow)
+			require.NoError(t, result.Err)
+			require.Zero(t, exitCode)
+		})
+		return result
+	}
+
+	env := append(osEnv(t), app.EnvCache+"="+t.TempDir())
+	result := generate(t, env)
+	require.Zero(t, result.Scan.PackagesCached.Load())
+	require.Equal(t, 3, result.Scan.Texts.Len())
+
+	// Nothing changed, all packages are reused.
+	result = generate(t, env)
+	require.Equal(t, int64(3), result.Scan.PackagesCached.Load())
+	require.Equal(t, 3, result.Scan.Texts.Len())
+	require.Equal(t, int64(3), result.Scan.StringCalls.Load())
+
+	// Only the changed package and the packages importing it are type checked.
+	writeFiles(t, dir, map[string]string{
+		"store/store.go": `
+			package store
+			import "tstmod/tokibundle"
+			func Title(r tokibundle.Reader) string {
+				return r.String("store") + r.String("new")
 			}
 		`,
 	})
+	result = generate(t, env)
+	require.Equal(t, int64(1), result.Scan.PackagesCached.Load())
+	require.Equal(t, 4, result.Scan.Texts.Len())
+	require.Len(t, result.NewTexts, 1)
+	require.Equal(t, "new", result.NewTexts[0].TIK.Raw)
+
+	// Disabled cache.
+	result = generate(t, append(osEnv(t), app.EnvCache+"=off"))
+	require.Zero(t, result.Scan.PackagesCached.Load())
+	require.Equal(t, 4, result.Scan.Texts.Len())
+}
 
-	runInDir(t, dir, func() {
-		args := []string{"toki", "generate", "-l=en"}
-		result, exitCode := app.Run(args, osEnv(), io.Discard, io.Discard, TimeNow)
-		require.NoError(t, result.Err)
-		require.Zero(t, exitCode)
-		// The same TIK in different domains must produce distinct message IDs.
-		require.Zero(t, result.Scan.SourceErrors.Len(),
-			"expected no source errors (no collision)")
-		require.Equal(t, 2, result.Scan.Texts.Len(),
-			"expected 2 distinct texts from 2 different domains")
+func TestGenerateWorkspace(t *testing.T) {
+	// Nested go commands can't use -mod=mod in workspace mode.
+	env := append(osEnv(t), "GOFLAGS=")
+	generate := func(t *testing.T, dir string, args ...string) app.Result {
+		t.Helper()
+		var result app.Result
+		runInDir(t, dir, func() {
+			var exitCode int
+			args = append([]string{"toki", "generate", "-l=en"}, args...)
+			result, exitCode = app.Run(args, env, io.Discard, io.Discard, TimeNow)
+			require.NoError(t, result.Err)
+			require.Zero(t, exitCode)
+		})
+		return result
+	}
+
+	t.Run("shared", func(t *testing.T) {
+		dir := initWorkspace(t, map[string]string{
+			"moda/main.go": `
+				package main
+				import (
+					"moda/tokibundle"
+					"modb/greet"
+				)
+				func main() {
+					r := tokibundle.Default()
+					print(r.String("main"), greet.Hello(r))
+				}
+			`,
+			"modb/greet/greet.go": `
+				package greet
+				import "moda/tokibundle"
+				func Hello(r tokibundle.Reader) string { return r.String("hello") }
+			`,
+		})
+		modA := filepath.Join(dir, "moda")
+
+		// The workspace is ignored by default.
+		result := generate(t, modA)
+		require.Equal(t, 1, result.Scan.Texts.Len())
+		require.Equal(t, filepath.FromSlash("/main.go"),
+			result.Scan.Texts.At(0).Position.Filename)
+
+		result = generate(t, modA, "-workspace=shared")
+		require.Equal(t, 2, result.Scan.Texts.Len())
+		var files []string
+		for text := range result.Scan.Texts.SeqRead() {
+			files = append(files, text.Position.Filename)
+		}
+		slices.Sort(files)
+		require.Equal(t, []string{
+			filepath.FromSlash("/moda/main.go"),
+			filepath.FromSlash("/modb/greet/greet.go"),
+		}, files)
+		require.FileExists(t, filepath.Join(dir, "moda", codeparse.DomainFileName))
+		require.NoFileExists(t, filepath.Join(dir, "modb", codeparse.DomainFileName))
+		require.NoDirExists(t, filepath.Join(dir, "modb", "tokibundle"))
+	})
+
+	t.Run("per-module", func(t *testing.T) {
+		dir := initWorkspace(t, map[string]string{
+			"moda/main.go": `
+				package main
+				import "moda/tokibundle"
+				func main() { print(tokibundle.Default().String("a")) }
+			`,
+			"modb/main.go": `
+				package main
+				import "modb/tokibundle"
+				func main() {
+					r := tokibundle.Default()
+					print(r.String("b"), r.String("c"))
+				}
+			`,
+		})
+
+		result := generate(t, filepath.Join(dir, "moda"), "-workspace=per-module")
+		require.Len(t, result.Modules, 2)
+		require.Equal(t, 1, result.Modules[0].Scan.Texts.Len())
+		require.Equal(t, 2, result.Modules[1].Scan.Texts.Len())
+		for _, mod := range []string{"moda", "modb"} {
+			require.FileExists(t, filepath.Join(
+				dir, mod, "tokibundle", app.MainBundleFileGo,
+			))
+		}
 	})
 }
 
@@ -694,6 +2708,17 @@ func TestGenerateErr(t *testing.T) {
 				require.Equal(t, "bundle contains incomplete catalogs", err.Error())
 			},
 		},
+		{
+			name: "watch and check",
+			setup: Setup{
+				InitGoMod: true, InitBundle: true,
+			},
+			args:           []string{"-watch", "-check"},
+			expectExitCode: 2,
+			expectErr: func(tt require.TestingT, err error, i ...any) {
+				require.ErrorIs(tt, err, app.ErrInvalidCLIArgs)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -808,6 +2833,121 @@ func TestGenerateErrSource(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "ERR lint invalid directive",
+			setup: Setup{
+				InitGoMod: true, InitBundle: true,
+				FilesAfterInit: map[string]string{
+					"main.go": `
+					package main
+					import "tstmod/tokibundle"
+					func main() {
+						//toki:maxlen many
+						//toki:example var1=Alice
+						//toki:unknown
+						print(tokibundle.Default().String("Hello {text}", "Bob"))
+					}
+					`,
+				},
+			},
+			args: []string{"lint", "-l=en"},
+			expectSrcErrs: []SourceError{
+				{"main.go:7:13", errHasMsg(
+					`invalid directive: //toki:maxlen: "many" must be a positive integer`,
+				)},
+				{"main.go:7:13", errHasMsg(
+					`invalid directive: //toki:example: unknown placeholder "var1"`,
+				)},
+				{"main.go:7:13", errHasMsg(
+					`invalid directive: unknown directive "//toki:unknown"`,
+				)},
+			},
+		},
+		{
+			name: "ERR lint forwarder call site",
+			setup: Setup{
+				InitGoMod: true, InitBundle: true,
+				FilesAfterInit: map[string]string{
+					"main.go": `
+					package main
+					import "tstmod/tokibundle"
+					//toki:forward tik=0 args=1
+					func t(tik string, args ...any) string {
+						return tokibundle.Default().String(tokibundle.TIK(tik), args...)
+					}
+					func main() {
+						tik := "Not a constant"
+						print(t("Expect {integer}", "a string"))
+						print(t(tik))
+					}
+					`,
+				},
+			},
+			args: []string{"lint", "-l=en"},
+			expectSrcErrs: []SourceError{
+				{
+					"main.go:9:15",
+					errHasMsg("TIK: arg 0 must be an integer but received: string"),
+				},
+				{"main.go:10:15", errHasMsg("TIK: not a constant")},
+			},
+		},
+		{
+			name: "ERR lint non-constant TIK conversion",
+			setup: Setup{
+				InitGoMod: true, InitBundle: true,
+				FilesAfterInit: map[string]string{
+					"main.go": `
+					package main
+					import (
+						"os"
+						"tstmod/tokibundle"
+					)
+					var label = tokibundle.TIK(os.Args[0])
+					func main() {
+						print(tokibundle.Default().String(tokibundle.TIK(os.Args[1])))
+						print(tokibundle.Default().String(label))
+					}
+					`,
+				},
+			},
+			args: []string{"lint", "-l=en"},
+			expectSrcErrs: []SourceError{
+				{"main.go:6:18", errHasMsg("TIK: not a constant")},
+				{"main.go:8:41", errHasMsg("TIK: not a constant")},
+			},
+		},
+		{
+			name: "ERR lint invalid forward directive",
+			setup: Setup{
+				InitGoMod: true, InitBundle: true,
+				FilesAfterInit: map[string]string{
+					"main.go": `
+					package main
+					import "tstmod/tokibundle"
+					//toki:forward tik=1
+					func t(tik string, n int) string {
+						return tokibundle.Default().String(tokibundle.TIK(tik), n)
+					}
+					//toki:forward tik=0
+					func u(tik string) string { return tik }
+					func main() { print(t("a", 1), u("b")) }
+					`,
+				},
+			},
+			args: []string{"lint", "-l=en"},
+			expectSrcErrs: []SourceError{
+				{"main.go:3:6", errHasMsg(
+					"invalid //toki:forward directive: " +
+						"parameter 1 must be a string or TIK but is int",
+				)},
+				{"main.go:5:42", errHasMsg("TIK: not a constant")},
+				{"main.go:7:6", errHasMsg(
+					"invalid //toki:forward directive: " +
+						"parameter \"tik\" isn't passed as TIK to any Reader method",
+				)},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -848,8 +2988,9 @@ func BenchmarkOKGenerate(b *testing.B) {
 	require.NoError(b, os.Chdir(tmp))
 
 	args := []string{"toki", "generate", "-l=en", "-q"}
+	env := osEnv(b)
 	for b.Loop() {
-		res, exitCode := app.Run(args, osEnv(), os.Stderr, os.Stdout, TimeNow)
+		res, exitCode := app.Run(args, env, os.Stderr, os.Stdout, TimeNow)
 		if res.Err != nil {
 			b.Fatalf("unexpected error: %v", res.Err)
 		}
@@ -859,6 +3000,101 @@ func BenchmarkOKGenerate(b *testing.B) {
 	}
 }
 
+// BenchmarkDispatchGenerated compares the perfect hash dispatch of a generated
+// bundle with the map lookup by TIK it replaced for a short and a long TIK
+// among many similar ones, and measures Reader.String end to end.
+// The benchmarks run in a test binary built for the generated bundle package
+// and their ns/op are reported.
+func BenchmarkDispatchGenerated(b *testing.B) {
+	lorem := strings.Repeat("Lorem ipsum dolor sit amet, consectetur adipiscing elit, "+
+		"sed do eiusmod tempor incididunt ut labore et dolore magna aliqua. ", 4)
+	var src strings.Builder
+	src.WriteString("package main\nimport \"tstmod/tokibundle\"\nfunc main() {\n")
+	src.WriteString("r := tokibundle.Default()\n")
+	tiks := make(map[string]string)
+	for i := range 20 {
+		tiks["short"] = fmt.Sprintf("Save %d", i)
+		tiks["long"] = fmt.Sprintf("%s%d%s", lorem, i, lorem)
+		fmt.Fprintf(&src, "print(r.String(%q))\nprint(r.String(%q))\n",
+			tiks["short"], tiks["long"])
+	}
+	src.WriteString("}\n")
+
+	s := Setup{
+		InitGoMod: true, InitBundle: true,
+		Files: map[string]string{"main.go": src.String()},
+	}
+	dir, res, _ := s.generate(b, TimeNow, "-l=en", "-q")
+	require.NoError(b, res.Err)
+	writeFiles(b, dir, map[string]string{"tokibundle/dispatch_test.go": fmt.Sprintf(`
+		package tokibundle
+		import "testing"
+		func BenchmarkDispatch(b *testing.B) {
+			// m is the map lookup by TIK used before the perfect hash.
+			m := make(map[TIK]int, len(tikTable))
+			for i, tik := range tikTable {
+				m[tik] = i
+			}
+			for tik, i := range tikFallback {
+				m[tik] = i
+			}
+			r := Default()
+			for name, tik := range map[string]TIK{"short": %q, "long": %q} {
+				b.Run(name+"/map", func(b *testing.B) {
+					for b.Loop() {
+						if _, ok := m[tik]; !ok {
+							b.Fatal("not found")
+						}
+					}
+				})
+				b.Run(name+"/phash", func(b *testing.B) {
+					for b.Loop() {
+						if tikIndex(tik) == -1 {
+							b.Fatal("not found")
+						}
+					}
+				})
+				b.Run(name+"/string", func(b *testing.B) {
+					for b.Loop() {
+						_ = r.String(tik)
+					}
+				})
+			}
+		}
+	`, tiks["short"], tiks["long"])})
+
+	bin := filepath.Join(b.TempDir(), "bundle.test")
+	cmd := exec.Command("go", "test", "-c", "-o", bin, "./tokibundle")
+	cmd.Dir = dir
+	cmd.Env = osEnv(b)
+	out, err := cmd.CombinedOutput()
+	require.NoError(b, err, string(out))
+
+	for _, kind := range []string{"short", "long"} {
+		for _, name := range []string{"map", "phash", "string"} {
+			b.Run(kind+"/"+name, func(b *testing.B) {
+				cmd := exec.Command(bin, "-test.run=^$",
+					"-test.bench=^BenchmarkDispatch$/^"+kind+"$/^"+name+"$",
+					fmt.Sprintf("-test.benchtime=%dx", b.N))
+				out, err := cmd.CombinedOutput()
+				require.NoError(b, err, string(out))
+				for line := range strings.Lines(string(out)) {
+					fields := strings.Fields(line)
+					if len(fields) < 4 ||
+						!strings.HasPrefix(fields[0], "BenchmarkDispatch/") {
+						continue
+					}
+					nsPerOp, err := strconv.ParseFloat(fields[2], 64)
+					require.NoError(b, err)
+					b.ReportMetric(nsPerOp, "ns/op")
+					return
+				}
+				b.Fatalf("missing benchmark result: %s", out)
+			})
+		}
+	}
+}
+
 func initGoMod(tb testing.TB, dir, name string) {
 	tb.Helper()
 	cmd := exec.Command("go", "mod", "init", name)
@@ -867,6 +3103,31 @@ func initGoMod(tb testing.TB, dir, name string) {
 	require.NoError(tb, err)
 }
 
+// initWorkspace creates a go.work workspace with the modules moda and modb
+// and writes files to it. Both modules require the dependencies of the bundle
+// since go commands can't update go.mod files in workspace mode.
+func initWorkspace(tb testing.TB, files map[string]string) (dir string) {
+	tb.Helper()
+	dir = tb.TempDir()
+	for _, mod := range []string{"moda", "modb"} {
+		modDir := filepath.Join(dir, mod)
+		require.NoError(tb, os.Mkdir(modDir, 0o755))
+		initGoMod(tb, modDir, mod)
+		cmd := exec.Command("go", "get",
+			"github.com/go-playground/locales", "golang.org/x/text")
+		cmd.Dir = modDir
+		cmd.Env = osEnv(tb)
+		out, err := cmd.CombinedOutput()
+		require.NoError(tb, err, string(out))
+	}
+	cmd := exec.Command("go", "work", "init", "./moda", "./modb")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(tb, err, string(out))
+	writeFiles(tb, dir, files)
+	return dir
+}
+
 var TimeNow = time.Date(2025, 1, 1, 1, 1, 1, 0, time.UTC)
 
 const ModName = "tstmod"
@@ -889,7 +3150,7 @@ func initBundle(
 		var exitCode int
 		result, exitCode = app.Run([]string{
 			"toki", "generate", "-l", locale.String(), "-b", bundlePkg,
-		}, osEnv(), stderr, stdout, TimeNow)
+		}, osEnv(tb), stderr, stdout, TimeNow)
 		require.Zero(tb, exitCode)
 	})
 	return result
@@ -946,16 +3207,17 @@ func (s Setup) generate(
 	}
 	writeFiles(tb, dir, s.FilesAfterInit)
 
+	env := osEnv(tb)
 	runInDir(tb, dir, func() {
 		a := append([]string{"toki", "generate"}, args...)
 		generateResult.Result, generateResult.ExitCode = app.Run(
-			a, osEnv(), stderr, stdout, now,
+			a, env, stderr, stdout, now,
 		)
 
 		ss := snapshotFiles(tb, dir)
 		a = append([]string{"toki", "lint"}, args...)
 		lintResult.Result, lintResult.ExitCode = app.Run(
-			a, osEnv(), stderr, stdout, now,
+			a, env, stderr, stdout, now,
 		)
 		ss.RequireUnchanged(tb, dir)
 	})
@@ -1018,6 +3280,23 @@ func (s FileSnapshot) RequireUnchanged(tb testing.TB, root string) {
 	}
 }
 
+func readARBFile(tb testing.TB, path string) *arb.File {
+	tb.Helper()
+	f, err := os.Open(path)
+	require.NoError(tb, err)
+	defer func() { _ = f.Close() }()
+	file, err := arb.NewDecoder().Decode(f)
+	require.NoError(tb, err)
+	return file
+}
+
+func writeARBFile(tb testing.TB, path string, file *arb.File) {
+	tb.Helper()
+	var buf bytes.Buffer
+	require.NoError(tb, arb.Encode(&buf, file, "\t"))
+	require.NoError(tb, os.WriteFile(path, buf.Bytes(), 0o644))
+}
+
 func stripLeadingSpaces(s string) string {
 	s = strings.TrimSpace(s)
 	lines := strings.Split(s, "\n")
@@ -1027,12 +3306,13 @@ func stripLeadingSpaces(s string) string {
 	return strings.Join(lines, "\n")
 }
 
-func osEnv() []string {
+func osEnv(tb testing.TB) []string {
 	// go test sets GOFLAGS to “-mod=readonly”, which prevents any nested go commands
 	// (those run by packages.Load, `go list`, or `go run` inside this test) from
 	// updating go.mod when new imports appear in the files generated by Toki.
 	// Overriding it with “-mod=mod” lets those inner commands record the missing
 	// dependencies automatically, so the second analysis pass and the final `go run`
 	// succeed.
-	return append(os.Environ(), "GOFLAGS=-mod=mod")
+	// The scan cache is isolated to never read or write the developer's cache.
+	return append(os.Environ(), "GOFLAGS=-mod=mod", app.EnvCache+"="+tb.TempDir())
 }